@@ -0,0 +1,482 @@
+package collections
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math/bits"
+)
+
+// BitSet is a set of small non-negative integers stored as a bit vector.
+//
+// Element i occupies bit i%64 of word i/64, so memory is proportional to the
+// largest element rather than the number of elements, and set algebra works
+// a word at a time. Add, Remove and Has are O(1); Len, Rank and the algebra
+// are O(n/64) for a largest element n. The zero value is an empty set ready
+// to use, and the set grows as needed up to MaxBitSetElement.
+type BitSet struct {
+	words []uint64
+}
+
+// MaxBitSetElement is the largest element a BitSet can hold. A set holding
+// it uses 512 MiB; for large or sparse elements use RoaringBitmap instead.
+const MaxBitSetElement uint = 1<<32 - 1
+
+const maxBitSetWords = int(MaxBitSetElement/64 + 1)
+
+// NewBitSet creates a new empty BitSet.
+func NewBitSet() *BitSet {
+	return &BitSet{}
+}
+
+// NewBitSetWithCapacity creates a bit set with room for elements below n
+// without growing. Room is capped at what MaxBitSetElement needs.
+func NewBitSetWithCapacity(n uint) *BitSet {
+	words := maxBitSetWords
+	if n <= MaxBitSetElement {
+		words = wordsFor(n)
+	}
+	return &BitSet{words: make([]uint64, 0, words)}
+}
+
+// NewBitSetFromSlice creates a bit set containing the elements of the slice.
+func NewBitSetFromSlice(s []uint) *BitSet {
+	b := NewBitSet()
+	for _, i := range s {
+		b.Add(i)
+	}
+	return b
+}
+
+func wordsFor(n uint) int {
+	w := int(n / 64)
+	if n%64 != 0 {
+		w++
+	}
+	return w
+}
+
+// grow makes sure the word holding bit i exists.
+func (b *BitSet) grow(i uint) {
+	if i > MaxBitSetElement {
+		panic(fmt.Sprintf("collections: BitSet element %d exceeds MaxBitSetElement", i))
+	}
+	if w := int(i/64) + 1; w > len(b.words) {
+		if n := len(b.words); w <= cap(b.words) {
+			b.words = b.words[:w]
+			clear(b.words[n:])
+		} else {
+			b.words = append(b.words, make([]uint64, w-len(b.words))...)
+		}
+	}
+}
+
+// Add adds i to the set, growing it to hold i. It panics if i exceeds
+// MaxBitSetElement.
+func (b *BitSet) Add(i uint) {
+	if b == nil {
+		return
+	}
+	b.grow(i)
+	b.words[i/64] |= 1 << (i % 64)
+}
+
+func (b *BitSet) Remove(i uint) {
+	if b == nil || i/64 >= uint(len(b.words)) {
+		return
+	}
+	b.words[i/64] &^= 1 << (i % 64)
+}
+
+func (b *BitSet) Has(i uint) bool {
+	if b == nil || i/64 >= uint(len(b.words)) {
+		return false
+	}
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Len returns the number of elements. Complexity: O(n/64).
+func (b *BitSet) Len() int {
+	if b == nil {
+		return 0
+	}
+	n := 0
+	for _, w := range b.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Clear removes all elements, keeping the allocated words for reuse.
+func (b *BitSet) Clear() {
+	if b == nil {
+		return
+	}
+	b.words = b.words[:0]
+}
+
+// NextSet returns the smallest element greater than or equal to i.
+func (b *BitSet) NextSet(i uint) (uint, bool) {
+	if b == nil {
+		return 0, false
+	}
+	w := i / 64
+	if w >= uint(len(b.words)) {
+		return 0, false
+	}
+	if word := b.words[w] >> (i % 64); word != 0 {
+		return i + uint(bits.TrailingZeros64(word)), true
+	}
+	for w++; w < uint(len(b.words)); w++ {
+		if word := b.words[w]; word != 0 {
+			return w*64 + uint(bits.TrailingZeros64(word)), true
+		}
+	}
+	return 0, false
+}
+
+// NextClear returns the smallest integer greater than or equal to i that is
+// not in the set.
+func (b *BitSet) NextClear(i uint) uint {
+	if b == nil {
+		return i
+	}
+	w := i / 64
+	if w >= uint(len(b.words)) {
+		return i
+	}
+	if word := ^b.words[w] >> (i % 64); word != 0 {
+		return i + uint(bits.TrailingZeros64(word))
+	}
+	for w++; w < uint(len(b.words)); w++ {
+		if word := ^b.words[w]; word != 0 {
+			return w*64 + uint(bits.TrailingZeros64(word))
+		}
+	}
+	return uint(len(b.words)) * 64
+}
+
+// Rank returns the number of elements strictly less than i.
+func (b *BitSet) Rank(i uint) int {
+	if b == nil {
+		return 0
+	}
+	n := 0
+	w := min(i/64, uint(len(b.words)))
+	for _, word := range b.words[:w] {
+		n += bits.OnesCount64(word)
+	}
+	if w < uint(len(b.words)) {
+		n += bits.OnesCount64(b.words[w] & (1<<(i%64) - 1))
+	}
+	return n
+}
+
+// Select returns the element with the given rank, that is the (n+1)-th
+// smallest element, or false if the set has n or fewer elements.
+func (b *BitSet) Select(n int) (uint, bool) {
+	if b == nil || n < 0 {
+		return 0, false
+	}
+	for w, word := range b.words {
+		c := bits.OnesCount64(word)
+		if n >= c {
+			n -= c
+			continue
+		}
+		for ; n > 0; n-- {
+			word &= word - 1 // drop the lowest set bit
+		}
+		return uint(w)*64 + uint(bits.TrailingZeros64(word)), true
+	}
+	return 0, false
+}
+
+// All returns an iterator over the elements in ascending order.
+func (b *BitSet) All() iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		if b == nil {
+			return
+		}
+		for w := 0; w < len(b.words); w++ {
+			for word := b.words[w]; word != 0; word &= word - 1 {
+				if !yield(uint(w)*64 + uint(bits.TrailingZeros64(word))) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Values returns the elements in ascending order.
+func (b *BitSet) Values() []uint {
+	if b.Len() == 0 {
+		return nil
+	}
+	out := make([]uint, 0, b.Len())
+	for i := range b.All() {
+		out = append(out, i)
+	}
+	return out
+}
+
+// ToSlice returns the elements in ascending order.
+func (b *BitSet) ToSlice() []uint {
+	return b.Values()
+}
+
+// Clone returns a copy of the set.
+func (b *BitSet) Clone() *BitSet {
+	if b == nil {
+		return NewBitSet()
+	}
+	return &BitSet{words: append([]uint64(nil), b.trimmed()...)}
+}
+
+func (b *BitSet) Union(other *BitSet) *BitSet {
+	out := b.cloneFor(other)
+	out.UnionWith(other)
+	return out
+}
+
+// cloneFor copies b with room for the words of other.
+func (b *BitSet) cloneFor(other *BitSet) *BitSet {
+	bw := b.trimmed()
+	words := make([]uint64, len(bw), max(len(bw), len(other.trimmed())))
+	copy(words, bw)
+	return &BitSet{words: words}
+}
+
+func (b *BitSet) Intersection(other *BitSet) *BitSet {
+	out := b.Clone()
+	out.IntersectWith(other)
+	return out
+}
+
+func (b *BitSet) Difference(other *BitSet) *BitSet {
+	out := b.Clone()
+	out.DifferenceWith(other)
+	return out
+}
+
+func (b *BitSet) SymmetricDifference(other *BitSet) *BitSet {
+	out := b.cloneFor(other)
+	out.SymmetricDifferenceWith(other)
+	return out
+}
+
+// UnionWith adds the elements of other to b and reports whether b changed.
+func (b *BitSet) UnionWith(other *BitSet) bool {
+	if b == nil || other == nil {
+		return false
+	}
+	ow := other.trimmed()
+	if len(ow) > len(b.words) {
+		b.grow(uint(len(ow))*64 - 1)
+	}
+	changed := false
+	for i, w := range ow {
+		if w&^b.words[i] != 0 {
+			b.words[i] |= w
+			changed = true
+		}
+	}
+	return changed
+}
+
+// IntersectWith removes the elements of b that are not in other and reports
+// whether b changed.
+func (b *BitSet) IntersectWith(other *BitSet) bool {
+	if b == nil {
+		return false
+	}
+	var ow []uint64
+	if other != nil {
+		ow = other.words
+	}
+	changed := false
+	for i, w := range b.words {
+		var keep uint64
+		if i < len(ow) {
+			keep = ow[i]
+		}
+		if w&^keep != 0 {
+			b.words[i] = w & keep
+			changed = true
+		}
+	}
+	return changed
+}
+
+// DifferenceWith removes the elements of other from b and reports whether b
+// changed.
+func (b *BitSet) DifferenceWith(other *BitSet) bool {
+	if b == nil || other == nil {
+		return false
+	}
+	changed := false
+	for i := range min(len(b.words), len(other.words)) {
+		if b.words[i]&other.words[i] != 0 {
+			b.words[i] &^= other.words[i]
+			changed = true
+		}
+	}
+	return changed
+}
+
+// SymmetricDifferenceWith replaces b with the elements that are in exactly
+// one of b and other, and reports whether b changed.
+func (b *BitSet) SymmetricDifferenceWith(other *BitSet) bool {
+	if b == nil || other == nil {
+		return false
+	}
+	ow := other.trimmed()
+	if len(ow) > len(b.words) {
+		b.grow(uint(len(ow))*64 - 1)
+	}
+	changed := false
+	for i, w := range ow {
+		if w != 0 {
+			b.words[i] ^= w
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (b *BitSet) IsSubset(other *BitSet) bool {
+	if b == nil {
+		return true
+	}
+	var ow []uint64
+	if other != nil {
+		ow = other.words
+	}
+	for i, w := range b.words {
+		if i >= len(ow) {
+			if w != 0 {
+				return false
+			}
+			continue
+		}
+		if w&^ow[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BitSet) IsSuperset(other *BitSet) bool {
+	return other.IsSubset(b)
+}
+
+func (b *BitSet) IsDisjoint(other *BitSet) bool {
+	if b == nil || other == nil {
+		return true
+	}
+	for i := range min(len(b.words), len(other.words)) {
+		if b.words[i]&other.words[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether b and other contain the same elements.
+func (b *BitSet) Equal(other *BitSet) bool {
+	bw, ow := b.trimmed(), other.trimmed()
+	if len(bw) != len(ow) {
+		return false
+	}
+	for i := range bw {
+		if bw[i] != ow[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimmed returns the words without trailing zero words.
+func (b *BitSet) trimmed() []uint64 {
+	if b == nil {
+		return nil
+	}
+	n := len(b.words)
+	for n > 0 && b.words[n-1] == 0 {
+		n--
+	}
+	return b.words[:n]
+}
+
+// MarshalBinary encodes the set as little-endian 64-bit words, with
+// trailing zero words omitted.
+func (b *BitSet) MarshalBinary() ([]byte, error) {
+	words := b.trimmed()
+	data := make([]byte, 0, 8*len(words))
+	for _, w := range words {
+		data = binary.LittleEndian.AppendUint64(data, w)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the contents of the set with data produced by
+// MarshalBinary. It returns an error if the data holds more words than a set
+// of elements up to MaxBitSetElement.
+func (b *BitSet) UnmarshalBinary(data []byte) error {
+	if b == nil {
+		return errors.New("collections: UnmarshalBinary on nil *BitSet")
+	}
+	if len(data)%8 != 0 {
+		return errors.New("collections: BitSet binary data is not a whole number of words")
+	}
+	if len(data)/8 > maxBitSetWords {
+		return errors.New("collections: BitSet binary data exceeds MaxBitSetElement")
+	}
+	b.words = b.words[:0]
+	for ; len(data) > 0; data = data[8:] {
+		b.words = append(b.words, binary.LittleEndian.Uint64(data))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the set as a JSON array in ascending order. A nil set
+// encodes as null.
+func (b *BitSet) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	values := b.Values()
+	if values == nil {
+		values = []uint{}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements of a JSON
+// array of non-negative integers. A JSON null leaves the set unchanged. It
+// returns an error, leaving the set unchanged, if an element exceeds
+// MaxBitSetElement.
+func (b *BitSet) UnmarshalJSON(data []byte) error {
+	if b == nil {
+		return errors.New("collections: UnmarshalJSON on nil *BitSet")
+	}
+	var values []uint
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		return nil
+	}
+	for _, i := range values {
+		if i > MaxBitSetElement {
+			return fmt.Errorf("collections: BitSet element %d exceeds MaxBitSetElement", i)
+		}
+	}
+	b.Clear()
+	for _, i := range values {
+		b.Add(i)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package collections
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBitSetBasic(t *testing.T) {
+	var b BitSet
+	for _, i := range []uint{3, 64, 0, 200, 64} {
+		b.Add(i)
+	}
+	if b.Len() != 4 || !b.Has(64) || b.Has(65) || b.Has(100000) {
+		t.Fatalf("len %d values %v", b.Len(), b.Values())
+	}
+	if got := b.Values(); !reflect.DeepEqual(got, []uint{0, 3, 64, 200}) {
+		t.Fatalf("values %v", got)
+	}
+	b.Remove(64)
+	b.Remove(100000)
+	if b.Has(64) || b.Len() != 3 {
+		t.Fatalf("remove")
+	}
+	b.Clear()
+	if b.Len() != 0 || b.Values() != nil {
+		t.Fatalf("clear")
+	}
+}
+
+func TestBitSetScanning(t *testing.T) {
+	b := NewBitSetFromSlice([]uint{1, 2, 3, 63, 64, 130})
+	next := map[uint]uint{0: 1, 3: 3, 4: 63, 65: 130}
+	for from, want := range next {
+		if got, ok := b.NextSet(from); !ok || got != want {
+			t.Fatalf("NextSet(%d) = %d %v, want %d", from, got, ok, want)
+		}
+	}
+	if _, ok := b.NextSet(131); ok {
+		t.Fatalf("NextSet past the end")
+	}
+	clearAt := map[uint]uint{0: 0, 1: 4, 63: 65, 130: 131, 500: 500}
+	for from, want := range clearAt {
+		if got := b.NextClear(from); got != want {
+			t.Fatalf("NextClear(%d) = %d, want %d", from, got, want)
+		}
+	}
+	full := NewBitSet()
+	for i := uint(0); i < 128; i++ {
+		full.Add(i)
+	}
+	if got := full.NextClear(5); got != 128 {
+		t.Fatalf("NextClear on full words = %d", got)
+	}
+
+	for r, v := range b.Values() {
+		if got := b.Rank(v); got != r {
+			t.Fatalf("Rank(%d) = %d, want %d", v, got, r)
+		}
+		if got, ok := b.Select(r); !ok || got != v {
+			t.Fatalf("Select(%d) = %d %v, want %d", r, got, ok, v)
+		}
+	}
+	if b.Rank(1000) != 6 || b.Rank(0) != 0 {
+		t.Fatalf("rank bounds")
+	}
+	if _, ok := b.Select(6); ok {
+		t.Fatalf("Select past the end")
+	}
+}
+
+func TestBitSetAlgebraMatchesSet(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() (*BitSet, *Set[uint]) {
+		b, s := NewBitSet(), NewSet[uint]()
+		n := rng.Intn(300)
+		for range rng.Intn(60) {
+			v := uint(rng.Intn(n + 1))
+			b.Add(v)
+			s.Add(v)
+		}
+		return b, s
+	}
+	same := func(b *BitSet, s *Set[uint]) bool {
+		return b.Len() == s.Len() && s.ContainsAllSeq(b.All())
+	}
+	for round := 0; round < 200; round++ {
+		a, sa := random()
+		b, sb := random()
+		checks := []struct {
+			name string
+			got  *BitSet
+			want *Set[uint]
+		}{
+			{"union", a.Union(b), sa.Union(sb)},
+			{"intersection", a.Intersection(b), sa.Intersection(sb)},
+			{"difference", a.Difference(b), sa.Difference(sb)},
+			{"symmetric difference", a.SymmetricDifference(b), sa.SymmetricDifference(sb)},
+		}
+		for _, c := range checks {
+			if !same(c.got, c.want) {
+				t.Fatalf("round %d %s: %v, want %d elements", round, c.name, c.got.Values(), c.want.Len())
+			}
+		}
+		if a.IsSubset(b) != sa.IsSubset(sb) || a.IsDisjoint(b) != sa.IsDisjoint(sb) || a.Equal(b) != sa.Equal(sb) {
+			t.Fatalf("round %d predicates", round)
+		}
+
+		c := a.Clone()
+		if changed := c.UnionWith(b); changed != !sb.IsSubset(sa) {
+			t.Fatalf("round %d UnionWith changed = %v", round, changed)
+		}
+		c = a.Clone()
+		if changed := c.IntersectWith(b); changed != !sa.IsSubset(sb) {
+			t.Fatalf("round %d IntersectWith changed = %v", round, changed)
+		}
+	}
+}
+
+func TestBitSetEqualIgnoresCapacity(t *testing.T) {
+	a := NewBitSetFromSlice([]uint{1, 500})
+	a.Remove(500)
+	b := NewBitSetFromSlice([]uint{1})
+	if !a.Equal(b) || !b.Equal(a) || !a.IsSubset(b) {
+		t.Fatalf("trailing zero words must not affect equality")
+	}
+	var nilSet *BitSet
+	if !nilSet.Equal(NewBitSet()) || nilSet.Equal(b) {
+		t.Fatalf("nil equality")
+	}
+}
+
+func TestBitSetGrowAfterShrink(t *testing.T) {
+	b := NewBitSetFromSlice([]uint{700})
+	if err := b.UnmarshalBinary([]byte{1, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	b.Add(640)
+	if got := b.Values(); !reflect.DeepEqual(got, []uint{0, 640}) {
+		t.Fatalf("stale bits after regrowing: %v", got)
+	}
+}
+
+func TestBitSetEncoding(t *testing.T) {
+	b := NewBitSetFromSlice([]uint{5, 1, 129})
+	data, err := b.MarshalBinary()
+	if err != nil || len(data) != 24 {
+		t.Fatalf("binary %d bytes, %v", len(data), err)
+	}
+	var back BitSet
+	if err := back.UnmarshalBinary(data); err != nil || !back.Equal(b) {
+		t.Fatalf("binary round trip %v %v", back.Values(), err)
+	}
+	if err := back.UnmarshalBinary([]byte{1, 2, 3}); err == nil {
+		t.Fatalf("expected error for partial word")
+	}
+
+	js, err := json.Marshal(b)
+	if err != nil || string(js) != "[1,5,129]" {
+		t.Fatalf("json %s %v", js, err)
+	}
+	if js, _ := json.Marshal(NewBitSet()); string(js) != "[]" {
+		t.Fatalf("empty json %s", js)
+	}
+	if err := json.Unmarshal([]byte("[7,3]"), &back); err != nil || !reflect.DeepEqual(back.Values(), []uint{3, 7}) {
+		t.Fatalf("json round trip %v %v", back.Values(), err)
+	}
+	if err := json.Unmarshal([]byte("[-1]"), &back); err == nil {
+		t.Fatalf("expected error for negative element")
+	}
+	if err := json.Unmarshal([]byte("[1,4294967296]"), &back); err == nil || back.Len() != 2 {
+		t.Fatalf("element above MaxBitSetElement should fail and leave the set unchanged: %v", err)
+	}
+}
+
+func TestBitSetMaxElement(t *testing.T) {
+	if wordsFor(MaxBitSetElement) != maxBitSetWords {
+		t.Fatalf("wordsFor(MaxBitSetElement) = %d", wordsFor(MaxBitSetElement))
+	}
+	if MaxBitSetElement == ^uint(0) {
+		t.Skip("uint cannot exceed MaxBitSetElement")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Add above MaxBitSetElement should panic")
+		}
+	}()
+	i := MaxBitSetElement
+	NewBitSet().Add(i + 1)
+}
+
+func TestBitSetNil(t *testing.T) {
+	var b *BitSet
+	b.Add(1)
+	b.Remove(1)
+	b.Clear()
+	if b.Has(1) || b.Len() != 0 || b.Rank(5) != 0 || b.NextClear(3) != 3 || b.Values() != nil {
+		t.Fatalf("nil set should behave as empty")
+	}
+	if _, ok := b.Select(0); ok {
+		t.Fatalf("nil select")
+	}
+	if b.Union(NewBitSetFromSlice([]uint{2})).Len() != 1 || !b.IsSubset(nil) {
+		t.Fatalf("nil algebra")
+	}
+}
+
+func BenchmarkBitSetUnion(b *testing.B) {
+	x, y := NewBitSet(), NewBitSet()
+	sx, sy := NewSet[uint](), NewSet[uint]()
+	for i := uint(0); i < 4096; i += 3 {
+		x.Add(i)
+		sx.Add(i)
+		y.Add(i + 1)
+		sy.Add(i + 1)
+	}
+	b.Run("BitSet", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			x.Union(y)
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sx.Union(sy)
+		}
+	})
+}
@@ -7,10 +7,18 @@
 // collections fills that gap with a small, focused set of types:
 //
 //   - Set[T]         : generic hash set with set algebra helpers
+//   - OrderedSet[T]  : insertion-ordered set with the same algebra
+//   - HashSet[T]     : set of non-comparable values using a Hasher
+//   - SortedSet[T]   : sorted set with range, rank and select queries
+//   - BitSet         : bit vector set of small non-negative integers
+//   - RoaringBitmap  : compressed bitmap for large sparse uint32 sets
 //   - Deque[T]       : double-ended queue based on a circular buffer
 //   - PriorityQueue[T] : generic heap-based priority queue
 //   - OrderedMap[K,V]: insertion-ordered map with stable iteration
+//   - SortedMap[K,V] : key-sorted map with range queries
 //   - MultiMap[K,V]  : map from key to multiple values (one-to-many)
+//   - LRU[K,V]       : fixed-capacity least-recently-used cache
+//   - ExpiringMap[K,V]: insertion-ordered map with per-entry TTL
 //
 // # Design goals
 //
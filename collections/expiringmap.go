@@ -0,0 +1,248 @@
+package collections
+
+import (
+	"iter"
+	"time"
+)
+
+// ExpiringMap is an insertion-ordered map whose entries can expire.
+//
+// Entries live in an OrderedMap; their deadlines are tracked in a
+// PriorityQueue so that Purge visits expired entries soonest-first without
+// scanning the whole map. Expired entries are removed lazily when Get or Has
+// finds them, or in bulk by Purge. Until then they still count towards Len,
+// but All and Keys skip them.
+//
+// The zero value is an empty map whose entries added by Set never expire,
+// like NewExpiringMap(0); methods on a nil *ExpiringMap behave like an empty
+// map.
+type ExpiringMap[K comparable, V any] struct {
+	m        OrderedMap[K, expiringEntry[V]]
+	expiries *PriorityQueue[expiry[K]]
+	ttl      time.Duration
+	now      func() time.Time
+	onExpire func(K, V)
+}
+
+type expiringEntry[V any] struct {
+	value    V
+	deadline time.Time // zero means the entry never expires
+}
+
+// expiry is a queued deadline. It is stale once its key has been deleted or
+// given a different deadline, and is then discarded when it reaches the top.
+type expiry[K any] struct {
+	deadline time.Time
+	key      K
+}
+
+// NewExpiringMap creates an empty map whose Set uses ttl as the time to
+// live. A ttl of zero or less means entries added by Set never expire.
+func NewExpiringMap[K comparable, V any](ttl time.Duration) *ExpiringMap[K, V] {
+	return &ExpiringMap[K, V]{ttl: ttl, now: time.Now}
+}
+
+// SetClock replaces the function used to read the current time, which is
+// time.Now by default. It is meant for tests. Passing nil restores time.Now.
+func (e *ExpiringMap[K, V]) SetClock(now func() time.Time) {
+	if e == nil {
+		return
+	}
+	if now == nil {
+		now = time.Now
+	}
+	e.now = now
+}
+
+// SetOnExpire registers fn to be called with each entry removed because it
+// expired, whether found by Get, Has or Purge. Entries removed by Delete or
+// Clear, or replaced by Set, are not reported. Passing nil removes the
+// callback.
+func (e *ExpiringMap[K, V]) SetOnExpire(fn func(key K, value V)) {
+	if e == nil {
+		return
+	}
+	e.onExpire = fn
+}
+
+// Set inserts or updates the value for the key using the map's default time
+// to live. Updating a key keeps its position and resets its deadline.
+func (e *ExpiringMap[K, V]) Set(k K, v V) {
+	e.SetWithTTL(k, v, e.defaultTTL())
+}
+
+// SetWithTTL inserts or updates the value for the key so that it expires
+// after ttl. A ttl of zero or less means the entry never expires.
+func (e *ExpiringMap[K, V]) SetWithTTL(k K, v V, ttl time.Duration) {
+	if e == nil {
+		return
+	}
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = e.clock().Add(ttl)
+		e.queue().Push(expiry[K]{deadline: deadline, key: k})
+	}
+	e.m.Set(k, expiringEntry[V]{value: v, deadline: deadline})
+	if e.expiries.Len() > 2*e.m.Len()+16 {
+		e.requeue()
+	}
+}
+
+// Get returns the value for the key. An expired entry is removed and
+// reported as absent.
+func (e *ExpiringMap[K, V]) Get(k K) (V, bool) {
+	if e == nil {
+		var zero V
+		return zero, false
+	}
+	ent, ok := e.m.Get(k)
+	if !ok || e.expire(k, ent, e.clock()) {
+		var zero V
+		return zero, false
+	}
+	return ent.value, true
+}
+
+// Has reports whether the key is present. An expired entry is removed and
+// reported as absent.
+func (e *ExpiringMap[K, V]) Has(k K) bool {
+	_, ok := e.Get(k)
+	return ok
+}
+
+// Deadline returns the time at which the key expires. The returned time is
+// zero if the entry never expires.
+func (e *ExpiringMap[K, V]) Deadline(k K) (time.Time, bool) {
+	if e == nil {
+		return time.Time{}, false
+	}
+	ent, ok := e.m.Get(k)
+	return ent.deadline, ok
+}
+
+// Delete removes the key if present, without calling the expiry callback.
+func (e *ExpiringMap[K, V]) Delete(k K) bool {
+	if e == nil {
+		return false
+	}
+	return e.m.Delete(k)
+}
+
+// Purge removes every entry whose deadline is at or before now, in deadline
+// order, calling the expiry callback for each. It returns the number of
+// entries removed. Complexity: O(k log n) for k expired entries, plus the
+// stale deadlines left behind by updates and deletes.
+func (e *ExpiringMap[K, V]) Purge(now time.Time) int {
+	if e == nil {
+		return 0
+	}
+	n := 0
+	q := e.queue()
+	for {
+		x, ok := q.Peek()
+		if !ok || x.deadline.After(now) {
+			return n
+		}
+		q.Pop()
+		if ent, ok := e.m.Get(x.key); ok && ent.deadline.Equal(x.deadline) && e.expire(x.key, ent, now) {
+			n++
+		}
+	}
+}
+
+// Len returns the number of entries, including expired entries that have
+// not yet been removed.
+func (e *ExpiringMap[K, V]) Len() int {
+	if e == nil {
+		return 0
+	}
+	return e.m.Len()
+}
+
+// Clear removes all entries without calling the expiry callback.
+func (e *ExpiringMap[K, V]) Clear() {
+	if e == nil {
+		return
+	}
+	e.m.Clear()
+	e.queue().Clear()
+}
+
+// All returns an iterator over the unexpired entries in insertion order.
+// Entries are checked against the clock when iteration starts; expired
+// entries are skipped but not removed.
+func (e *ExpiringMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if e == nil {
+			return
+		}
+		now := e.clock()
+		for k, ent := range e.m.All() {
+			if ent.expired(now) {
+				continue
+			}
+			if !yield(k, ent.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys of the unexpired entries in insertion order.
+func (e *ExpiringMap[K, V]) Keys() []K {
+	var keys []K
+	for k := range e.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (e *ExpiringMap[K, V]) defaultTTL() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.ttl
+}
+
+// clock returns the current time, defaulting to time.Now on a zero-value map.
+func (e *ExpiringMap[K, V]) clock() time.Time {
+	if e.now == nil {
+		return time.Now()
+	}
+	return e.now()
+}
+
+// queue returns the deadline queue, creating it on first use.
+func (e *ExpiringMap[K, V]) queue() *PriorityQueue[expiry[K]] {
+	if e.expiries == nil {
+		e.expiries = NewPriorityQueue(func(a, b expiry[K]) bool { return a.deadline.Before(b.deadline) })
+	}
+	return e.expiries
+}
+
+// expire removes the entry and reports it if it has expired at now.
+func (e *ExpiringMap[K, V]) expire(k K, ent expiringEntry[V], now time.Time) bool {
+	if !ent.expired(now) {
+		return false
+	}
+	e.m.Delete(k)
+	if e.onExpire != nil {
+		e.onExpire(k, ent.value)
+	}
+	return true
+}
+
+// requeue rebuilds the deadline queue from the live entries, dropping stale
+// deadlines left behind by updates and deletes.
+func (e *ExpiringMap[K, V]) requeue() {
+	e.expiries.Clear()
+	for k, ent := range e.m.All() {
+		if !ent.deadline.IsZero() {
+			e.expiries.Push(expiry[K]{deadline: ent.deadline, key: k})
+		}
+	}
+}
+
+func (ent expiringEntry[V]) expired(now time.Time) bool {
+	return !ent.deadline.IsZero() && !now.Before(ent.deadline)
+}
@@ -0,0 +1,138 @@
+package collections
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestExpiringMap(ttl time.Duration) (*ExpiringMap[string, int], *fakeClock, *[]string) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	m := NewExpiringMap[string, int](ttl)
+	m.SetClock(clock.now)
+	expired := new([]string)
+	m.SetOnExpire(func(k string, v int) { *expired = append(*expired, k) })
+	return m, clock, expired
+}
+
+func TestExpiringMapLazyExpiry(t *testing.T) {
+	m, clock, expired := newTestExpiringMap(time.Minute)
+	m.Set("a", 1)
+	m.SetWithTTL("b", 2, 10*time.Second)
+	m.SetWithTTL("forever", 3, 0)
+
+	clock.advance(10 * time.Second)
+	if m.Has("b") {
+		t.Fatalf("b should expire exactly at its deadline")
+	}
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("a = %d %v", v, ok)
+	}
+	if !reflect.DeepEqual(*expired, []string{"b"}) || m.Len() != 2 {
+		t.Fatalf("expired %v, len %d", *expired, m.Len())
+	}
+
+	clock.advance(time.Hour)
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"forever"}) {
+		t.Fatalf("keys %v", got)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("iteration must not remove expired entries, len %d", m.Len())
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatalf("a should have expired")
+	}
+	if d, ok := m.Deadline("forever"); !ok || !d.IsZero() {
+		t.Fatalf("deadline of forever %v %v", d, ok)
+	}
+}
+
+func TestExpiringMapPurgeInDeadlineOrder(t *testing.T) {
+	m, clock, expired := newTestExpiringMap(0)
+	m.SetWithTTL("slow", 1, 30*time.Second)
+	m.SetWithTTL("fast", 2, 10*time.Second)
+	m.SetWithTTL("mid", 3, 20*time.Second)
+	m.SetWithTTL("late", 4, time.Hour)
+	m.Set("never", 5)
+
+	// Refreshing a key moves its deadline; deleting one drops it.
+	m.SetWithTTL("fast", 2, 25*time.Second)
+	m.Delete("mid")
+
+	if n := m.Purge(clock.t.Add(15 * time.Second)); n != 0 {
+		t.Fatalf("purged %d entries too early", n)
+	}
+	if n := m.Purge(clock.t.Add(time.Minute)); n != 2 {
+		t.Fatalf("purged %d, want 2", n)
+	}
+	if !reflect.DeepEqual(*expired, []string{"fast", "slow"}) {
+		t.Fatalf("expired %v", *expired)
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"late", "never"}) {
+		t.Fatalf("keys %v", got)
+	}
+}
+
+func TestExpiringMapUpdateKeepsPosition(t *testing.T) {
+	m, clock, _ := newTestExpiringMap(time.Minute)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	clock.advance(50 * time.Second)
+	m.Set("a", 10)
+	clock.advance(20 * time.Second)
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("keys %v", got)
+	}
+	m.Set("c", 3)
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Fatalf("keys %v", got)
+	}
+}
+
+func TestExpiringMapStaleDeadlinesAreBounded(t *testing.T) {
+	m, clock, _ := newTestExpiringMap(time.Minute)
+	for i := 0; i < 10_000; i++ {
+		m.Set("k", i)
+		clock.advance(time.Millisecond)
+	}
+	if n := m.expiries.Len(); n > 2*m.Len()+16 {
+		t.Fatalf("deadline queue grew to %d", n)
+	}
+	if v, _ := m.Get("k"); v != 9999 {
+		t.Fatalf("k = %d", v)
+	}
+}
+
+func TestExpiringMapNil(t *testing.T) {
+	var m *ExpiringMap[string, int]
+	m.Set("a", 1)
+	m.SetClock(nil)
+	m.Clear()
+	if m.Has("a") || m.Len() != 0 || m.Purge(time.Now()) != 0 || m.Delete("a") || m.Keys() != nil {
+		t.Fatalf("nil map should behave as empty")
+	}
+}
+
+func TestExpiringMapZeroValue(t *testing.T) {
+	var m ExpiringMap[string, int]
+	if m.Purge(time.Now()) != 0 || m.Has("a") {
+		t.Fatalf("zero map should read as empty")
+	}
+	m.Set("forever", 1)
+	m.SetWithTTL("soon", 2, time.Hour)
+	if v, ok := m.Get("soon"); !ok || v != 2 {
+		t.Fatalf("soon = %d %v", v, ok)
+	}
+	if n := m.Purge(time.Now().Add(2 * time.Hour)); n != 1 || !reflect.DeepEqual(m.Keys(), []string{"forever"}) {
+		t.Fatalf("purged %d, keys %v", n, m.Keys())
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Fatalf("len after clear %d", m.Len())
+	}
+}
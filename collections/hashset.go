@@ -0,0 +1,477 @@
+package collections
+
+import (
+	"iter"
+	"slices"
+)
+
+// Hasher computes a hash for values of type T. It has the same shape as
+// concurrent.Hasher. Values that are equal must hash the same.
+type Hasher[T any] func(T) uint64
+
+// HashSet is a set of values of any type, including non-comparable ones
+// such as []byte or structs containing slices.
+//
+// Values are grouped in buckets by the result of a Hasher and told apart by
+// an equality function, so Add, Remove and Has are O(1) on average given a
+// well-distributed hash. Values must not be modified while they are in the
+// set. A HashSet must be created with NewHashSet; methods on a nil *HashSet
+// behave like an empty set. Sets returned by the algebra methods use the
+// receiver's hash and equality functions; with a nil receiver they use the
+// argument's, and are nil only if both sets are nil.
+type HashSet[T any] struct {
+	buckets map[uint64][]T
+	hash    Hasher[T]
+	equal   func(a, b T) bool
+	n       int
+}
+
+// NewHashSet creates an empty set that hashes values with hash and compares
+// them with equal.
+func NewHashSet[T any](hash Hasher[T], equal func(a, b T) bool) *HashSet[T] {
+	return &HashSet[T]{buckets: make(map[uint64][]T), hash: hash, equal: equal}
+}
+
+// NewHashSetFromSlice creates a set containing the elements of the slice.
+func NewHashSetFromSlice[T any](hash Hasher[T], equal func(a, b T) bool, s []T) *HashSet[T] {
+	set := NewHashSet(hash, equal)
+	for _, v := range s {
+		set.Add(v)
+	}
+	return set
+}
+
+// find returns the bucket key for v and v's index in it, or -1.
+func (s *HashSet[T]) find(v T) (uint64, int) {
+	h := s.hash(v)
+	for i, x := range s.buckets[h] {
+		if s.equal(x, v) {
+			return h, i
+		}
+	}
+	return h, -1
+}
+
+// add inserts v and reports whether it was absent.
+func (s *HashSet[T]) add(v T) bool {
+	h, i := s.find(v)
+	if i >= 0 {
+		return false
+	}
+	s.buckets[h] = append(s.buckets[h], v)
+	s.n++
+	return true
+}
+
+// remove deletes v and reports whether it was present.
+func (s *HashSet[T]) remove(v T) bool {
+	h, i := s.find(v)
+	if i < 0 {
+		return false
+	}
+	s.removeAt(h, i)
+	return true
+}
+
+func (s *HashSet[T]) removeAt(h uint64, i int) {
+	b := s.buckets[h]
+	last := len(b) - 1
+	b[i] = b[last]
+	var zero T
+	b[last] = zero
+	if last == 0 {
+		delete(s.buckets, h)
+	} else {
+		s.buckets[h] = b[:last]
+	}
+	s.n--
+}
+
+func (s *HashSet[T]) Add(v T) {
+	if s == nil {
+		return
+	}
+	s.add(v)
+}
+
+func (s *HashSet[T]) Remove(v T) {
+	if s == nil {
+		return
+	}
+	s.remove(v)
+}
+
+func (s *HashSet[T]) Has(v T) bool {
+	if s == nil || s.n == 0 {
+		return false
+	}
+	_, i := s.find(v)
+	return i >= 0
+}
+
+func (s *HashSet[T]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.n
+}
+
+func (s *HashSet[T]) Clear() {
+	if s == nil {
+		return
+	}
+	clear(s.buckets)
+	s.n = 0
+}
+
+// All returns an iterator over the elements in the set. The order is
+// undefined.
+func (s *HashSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s == nil {
+			return
+		}
+		for _, b := range s.buckets {
+			for _, v := range b {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *HashSet[T]) Values() []T {
+	if s.Len() == 0 {
+		return nil
+	}
+	out := make([]T, 0, s.n)
+	for _, b := range s.buckets {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// ToSlice returns a slice containing the elements of the set.
+// The order of elements is undefined.
+func (s *HashSet[T]) ToSlice() []T {
+	return s.Values()
+}
+
+// Clone returns a shallow copy of the set. Cloning a nil set returns nil,
+// since there are no hash and equality functions to copy.
+func (s *HashSet[T]) Clone() *HashSet[T] {
+	if s == nil {
+		return nil
+	}
+	out := &HashSet[T]{buckets: make(map[uint64][]T, len(s.buckets)), hash: s.hash, equal: s.equal, n: s.n}
+	for h, b := range s.buckets {
+		out.buckets[h] = slices.Clone(b)
+	}
+	return out
+}
+
+// empty returns an empty set sharing s's functions, or nil if s is nil.
+func (s *HashSet[T]) empty() *HashSet[T] {
+	if s == nil {
+		return nil
+	}
+	return NewHashSet(s.hash, s.equal)
+}
+
+// Union returns a new set with the elements of s and other. If s is nil the
+// result is a copy of other.
+func (s *HashSet[T]) Union(other *HashSet[T]) *HashSet[T] {
+	if s == nil {
+		return other.Clone()
+	}
+	out := s.Clone()
+	out.UnionWith(other)
+	return out
+}
+
+// Intersection returns a new set with the elements in both s and other.
+func (s *HashSet[T]) Intersection(other *HashSet[T]) *HashSet[T] {
+	if s == nil {
+		return other.empty()
+	}
+	out := s.empty()
+	small, large := s, other
+	if large.Len() < small.Len() {
+		small, large = large, small
+	}
+	for v := range small.All() {
+		if large.Has(v) {
+			out.add(v)
+		}
+	}
+	return out
+}
+
+// Difference returns a new set with the elements of s that are not in
+// other.
+func (s *HashSet[T]) Difference(other *HashSet[T]) *HashSet[T] {
+	if s == nil {
+		return other.empty()
+	}
+	out := s.empty()
+	for v := range s.All() {
+		if !other.Has(v) {
+			out.add(v)
+		}
+	}
+	return out
+}
+
+// SymmetricDifference returns a new set with the elements in exactly one of
+// s and other. If s is nil the result is a copy of other.
+func (s *HashSet[T]) SymmetricDifference(other *HashSet[T]) *HashSet[T] {
+	if s == nil {
+		return other.Clone()
+	}
+	out := s.Difference(other)
+	for v := range other.All() {
+		if !s.Has(v) {
+			out.add(v)
+		}
+	}
+	return out
+}
+
+// UnionWith adds the elements of other to s and reports whether s changed.
+func (s *HashSet[T]) UnionWith(other *HashSet[T]) bool {
+	if s == nil || s == other {
+		return false
+	}
+	changed := false
+	for v := range other.All() {
+		if s.add(v) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// IntersectWith removes the elements of s that are not in other and reports
+// whether s changed.
+func (s *HashSet[T]) IntersectWith(other *HashSet[T]) bool {
+	if s == other {
+		return false
+	}
+	return s.RemoveFunc(func(v T) bool { return !other.Has(v) }) > 0
+}
+
+// DifferenceWith removes the elements of other from s and reports whether s
+// changed.
+func (s *HashSet[T]) DifferenceWith(other *HashSet[T]) bool {
+	if s == nil || other.Len() == 0 {
+		return false
+	}
+	if s == other {
+		s.Clear()
+		return true
+	}
+	changed := false
+	for v := range other.All() {
+		if s.remove(v) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// SymmetricDifferenceWith replaces s with the elements that are in exactly
+// one of s and other, and reports whether s changed.
+func (s *HashSet[T]) SymmetricDifferenceWith(other *HashSet[T]) bool {
+	if s == nil || other.Len() == 0 {
+		return false
+	}
+	if s == other {
+		s.Clear()
+		return true
+	}
+	for v := range other.All() {
+		if !s.remove(v) {
+			s.add(v)
+		}
+	}
+	return true
+}
+
+func (s *HashSet[T]) IsSubset(other *HashSet[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for v := range s.All() {
+		if !other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *HashSet[T]) IsSuperset(other *HashSet[T]) bool {
+	return other.IsSubset(s)
+}
+
+func (s *HashSet[T]) IsDisjoint(other *HashSet[T]) bool {
+	if s.Len() > other.Len() {
+		s, other = other, s
+	}
+	for v := range s.All() {
+		if other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and other contain the same elements. A nil set
+// equals an empty one.
+func (s *HashSet[T]) Equal(other *HashSet[T]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
+
+// ContainsAll reports whether every one of vs is in the set. It reports true
+// when vs is empty.
+func (s *HashSet[T]) ContainsAll(vs ...T) bool {
+	return s.ContainsAllSeq(slices.Values(vs))
+}
+
+// ContainsAllSeq reports whether every element of seq is in the set,
+// stopping at the first one that is not.
+func (s *HashSet[T]) ContainsAllSeq(seq iter.Seq[T]) bool {
+	for v := range seq {
+		if !s.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether at least one of vs is in the set. It reports
+// false when vs is empty.
+func (s *HashSet[T]) ContainsAny(vs ...T) bool {
+	return s.ContainsAnySeq(slices.Values(vs))
+}
+
+// ContainsAnySeq reports whether at least one element of seq is in the set,
+// stopping at the first one that is.
+func (s *HashSet[T]) ContainsAnySeq(seq iter.Seq[T]) bool {
+	for v := range seq {
+		if s.Has(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAll adds each of vs to the set.
+func (s *HashSet[T]) AddAll(vs ...T) {
+	s.AddSeq(slices.Values(vs))
+}
+
+// AddSeq adds every element of seq to the set.
+func (s *HashSet[T]) AddSeq(seq iter.Seq[T]) {
+	if s == nil {
+		return
+	}
+	for v := range seq {
+		s.add(v)
+	}
+}
+
+// RemoveAll removes each of vs from the set.
+func (s *HashSet[T]) RemoveAll(vs ...T) {
+	s.RemoveSeq(slices.Values(vs))
+}
+
+// RemoveSeq removes every element of seq from the set.
+func (s *HashSet[T]) RemoveSeq(seq iter.Seq[T]) {
+	if s == nil {
+		return
+	}
+	for v := range seq {
+		s.remove(v)
+	}
+}
+
+// RemoveFunc removes the elements for which fn returns true and returns the
+// number removed.
+func (s *HashSet[T]) RemoveFunc(fn func(T) bool) int {
+	if s == nil {
+		return 0
+	}
+	n := s.n
+	for h, b := range s.buckets {
+		kept := b[:0]
+		for _, v := range b {
+			if !fn(v) {
+				kept = append(kept, v)
+			}
+		}
+		clear(b[len(kept):])
+		s.n -= len(b) - len(kept)
+		if len(kept) == 0 {
+			delete(s.buckets, h)
+		} else {
+			s.buckets[h] = kept
+		}
+	}
+	return n - s.n
+}
+
+// RetainFunc keeps only the elements for which fn returns true and returns
+// the number removed.
+func (s *HashSet[T]) RetainFunc(fn func(T) bool) int {
+	return s.RemoveFunc(func(v T) bool { return !fn(v) })
+}
+
+// Pop removes and returns an arbitrary element of the set.
+func (s *HashSet[T]) Pop() (T, bool) {
+	if s != nil {
+		for h, b := range s.buckets {
+			v := b[len(b)-1]
+			s.removeAt(h, len(b)-1)
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// BytesHasher hashes byte slices with 64-bit FNV-1a. Use it with
+// bytes.Equal.
+func BytesHasher(b []byte) uint64 {
+	return fnvAdd(fnvOffset64, b)
+}
+
+// StringsHasher hashes string slices with 64-bit FNV-1a, including each
+// string's length so that ["ab"] and ["a", "b"] hash differently. Use it with
+// slices.Equal.
+func StringsHasher(ss []string) uint64 {
+	h := uint64(fnvOffset64)
+	for _, s := range ss {
+		n := uint64(len(s))
+		for i := 0; i < 8; i++ {
+			h ^= n & 0xff
+			h *= fnvPrime64
+			n >>= 8
+		}
+		h = fnvAdd(h, s)
+	}
+	return h
+}
+
+const (
+	fnvOffset64 = 1469598103934665603
+	fnvPrime64  = 1099511628211
+)
+
+func fnvAdd[B []byte | string](h uint64, b B) uint64 {
+	for i := 0; i < len(b); i++ {
+		h ^= uint64(b[i])
+		h *= fnvPrime64
+	}
+	return h
+}
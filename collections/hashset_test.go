@@ -0,0 +1,168 @@
+package collections
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newBytesSet(vs ...string) *HashSet[[]byte] {
+	s := NewHashSet(BytesHasher, bytes.Equal)
+	for _, v := range vs {
+		s.Add([]byte(v))
+	}
+	return s
+}
+
+func sortedBytes(s *HashSet[[]byte]) []string {
+	var out []string
+	for v := range s.All() {
+		out = append(out, string(v))
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestHashSetBasic(t *testing.T) {
+	s := newBytesSet("a", "b", "a")
+	if s.Len() != 2 || !s.Has([]byte("a")) || s.Has([]byte("c")) {
+		t.Fatalf("len %d values %v", s.Len(), sortedBytes(s))
+	}
+	s.Remove([]byte("a"))
+	s.Remove([]byte("zz"))
+	if s.Has([]byte("a")) || s.Len() != 1 {
+		t.Fatalf("remove")
+	}
+	s.Clear()
+	if s.Len() != 0 || s.Values() != nil {
+		t.Fatalf("clear")
+	}
+}
+
+func TestHashSetCollisions(t *testing.T) {
+	// Every value lands in one bucket; equality still tells them apart.
+	s := NewHashSet(func([]int) uint64 { return 7 }, slices.Equal[[]int])
+	for i := range 10 {
+		s.Add([]int{i, i})
+	}
+	s.Add([]int{3, 3})
+	if s.Len() != 10 || !s.Has([]int{9, 9}) || s.Has([]int{9}) {
+		t.Fatalf("len %d", s.Len())
+	}
+	for i := range 10 {
+		s.Remove([]int{i, i})
+		if s.Len() != 9-i || s.Has([]int{i, i}) {
+			t.Fatalf("remove %d left %d", i, s.Len())
+		}
+	}
+	if len(s.buckets) != 0 {
+		t.Fatalf("empty bucket left behind")
+	}
+}
+
+func TestHashSetAlgebra(t *testing.T) {
+	a := newBytesSet("1", "2", "3", "4")
+	b := newBytesSet("3", "4", "5")
+	cases := []struct {
+		name string
+		got  *HashSet[[]byte]
+		want []string
+	}{
+		{"union", a.Union(b), []string{"1", "2", "3", "4", "5"}},
+		{"intersection", a.Intersection(b), []string{"3", "4"}},
+		{"difference", a.Difference(b), []string{"1", "2"}},
+		{"symmetric difference", a.SymmetricDifference(b), []string{"1", "2", "5"}},
+		{"nil union", (*HashSet[[]byte])(nil).Union(b), []string{"3", "4", "5"}},
+		{"nil intersection", (*HashSet[[]byte])(nil).Intersection(b), nil},
+		{"intersection with nil", a.Intersection(nil), nil},
+	}
+	for _, c := range cases {
+		if got := sortedBytes(c.got); !slices.Equal(got, c.want) {
+			t.Fatalf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+	if a.Len() != 4 || b.Len() != 3 {
+		t.Fatalf("inputs modified")
+	}
+
+	sub := newBytesSet("4", "3")
+	if !sub.IsSubset(a) || !a.IsSuperset(sub) || a.IsSubset(sub) || a.IsDisjoint(b) || !sub.IsDisjoint(newBytesSet("9")) {
+		t.Fatalf("predicates")
+	}
+	if !sub.Equal(newBytesSet("3", "4")) || sub.Equal(b) {
+		t.Fatalf("equal")
+	}
+
+	c := a.Clone()
+	if !c.UnionWith(b) || c.UnionWith(b) || c.Len() != 5 {
+		t.Fatalf("UnionWith")
+	}
+	if !c.IntersectWith(a) || c.IntersectWith(a) || !c.Equal(a) {
+		t.Fatalf("IntersectWith")
+	}
+	if !c.DifferenceWith(b) || c.DifferenceWith(b) || !c.Equal(newBytesSet("1", "2")) {
+		t.Fatalf("DifferenceWith")
+	}
+	if !c.SymmetricDifferenceWith(newBytesSet("2", "9")) || !c.Equal(newBytesSet("1", "9")) {
+		t.Fatalf("SymmetricDifferenceWith %v", sortedBytes(c))
+	}
+	if !c.SymmetricDifferenceWith(c) || c.Len() != 0 {
+		t.Fatalf("SymmetricDifferenceWith self")
+	}
+}
+
+func TestHashSetHelpers(t *testing.T) {
+	s := NewHashSet(StringsHasher, slices.Equal[[]string])
+	s.AddAll([]string{"a", "b"}, []string{"ab"}, []string{})
+	if s.Len() != 3 || !s.ContainsAll([]string{"ab"}, []string{}) || s.ContainsAny([]string{"a"}, []string{"b"}) {
+		t.Fatalf("values %v", s.Values())
+	}
+	if n := s.RemoveFunc(func(v []string) bool { return len(v) == 0 }); n != 1 {
+		t.Fatalf("RemoveFunc removed %d", n)
+	}
+	if n := s.RetainFunc(func(v []string) bool { return len(v) == 2 }); n != 1 || !s.Has([]string{"a", "b"}) {
+		t.Fatalf("RetainFunc removed %d", n)
+	}
+	s.RemoveAll([]string{"a", "b"})
+	if v, ok := s.Pop(); ok || s.Len() != 0 {
+		t.Fatalf("Pop on empty set returned %v", v)
+	}
+	s.AddSeq(slices.Values([][]string{{"x"}}))
+	if v, ok := s.Pop(); !ok || !slices.Equal(v, []string{"x"}) || s.Len() != 0 {
+		t.Fatalf("Pop %v %v", v, ok)
+	}
+}
+
+func TestHashers(t *testing.T) {
+	if BytesHasher([]byte("abc")) != BytesHasher([]byte("abc")) || BytesHasher([]byte("abc")) == BytesHasher([]byte("abd")) {
+		t.Fatalf("BytesHasher")
+	}
+	pairs := [][2][]string{
+		{{"ab"}, {"a", "b"}},
+		{{"a", ""}, {"a"}},
+		{{"", "a"}, {"a", ""}},
+		{nil, {""}},
+	}
+	for _, p := range pairs {
+		if StringsHasher(p[0]) == StringsHasher(p[1]) {
+			t.Fatalf("StringsHasher(%q) == StringsHasher(%q)", p[0], p[1])
+		}
+	}
+	if StringsHasher(strings.Fields("x y")) != StringsHasher([]string{"x", "y"}) {
+		t.Fatalf("StringsHasher not deterministic")
+	}
+}
+
+func TestHashSetNil(t *testing.T) {
+	var s *HashSet[[]byte]
+	s.Add([]byte("a"))
+	s.Remove([]byte("a"))
+	s.Clear()
+	if s.Has([]byte("a")) || s.Len() != 0 || s.Values() != nil || s.Clone() != nil || s.Union(nil) != nil {
+		t.Fatalf("nil set should behave as empty")
+	}
+	if !s.IsSubset(nil) || !s.Equal(newBytesSet()) || s.UnionWith(newBytesSet("a")) {
+		t.Fatalf("nil predicates")
+	}
+}
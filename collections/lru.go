@@ -0,0 +1,180 @@
+package collections
+
+import "iter"
+
+// LRU is a fixed-capacity cache that evicts the least recently used entry
+// when a new key would exceed its capacity.
+//
+// Entries are kept in an OrderedMap ordered from least to most recently used,
+// so Get, Set, Peek and Delete are O(1) on average. The zero value is an
+// empty cache with capacity 1, like NewLRU(0); methods on a nil *LRU behave
+// like an empty cache.
+type LRU[K comparable, V any] struct {
+	m        OrderedMap[K, V]
+	capacity int
+	onEvict  func(K, V)
+}
+
+// NewLRU creates an empty cache holding at most capacity entries.
+// A capacity below 1 is treated as 1.
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU[K, V]{m: *NewOrderedMap[K, V](), capacity: capacity}
+}
+
+// SetOnEvict registers fn to be called with each entry evicted to respect the
+// capacity, whether by Set or Resize. Entries removed by Delete or Clear are
+// not reported. Passing nil removes the callback.
+func (c *LRU[K, V]) SetOnEvict(fn func(key K, value V)) {
+	if c == nil {
+		return
+	}
+	c.onEvict = fn
+}
+
+// Set inserts or updates the value for the key and marks it most recently
+// used. It reports whether an older entry was evicted to make room.
+func (c *LRU[K, V]) Set(k K, v V) bool {
+	if c == nil {
+		return false
+	}
+	if c.m.Has(k) {
+		c.m.Set(k, v)
+		c.m.MoveToBack(k)
+		return false
+	}
+	c.m.Set(k, v)
+	return c.evict() > 0
+}
+
+// Get returns the value for the key and marks it most recently used.
+func (c *LRU[K, V]) Get(k K) (V, bool) {
+	if c == nil {
+		var zero V
+		return zero, false
+	}
+	v, ok := c.m.Get(k)
+	if ok {
+		c.m.MoveToBack(k)
+	}
+	return v, ok
+}
+
+// Peek returns the value for the key without changing its recency.
+func (c *LRU[K, V]) Peek(k K) (V, bool) {
+	if c == nil {
+		var zero V
+		return zero, false
+	}
+	return c.m.Get(k)
+}
+
+// Has reports whether the key is cached without changing its recency.
+func (c *LRU[K, V]) Has(k K) bool {
+	return c != nil && c.m.Has(k)
+}
+
+// Delete removes the key if present. The eviction callback is not called.
+func (c *LRU[K, V]) Delete(k K) bool {
+	return c != nil && c.m.Delete(k)
+}
+
+// Oldest returns the least recently used entry without changing its recency.
+func (c *LRU[K, V]) Oldest() (K, V, bool) {
+	if c == nil {
+		var (
+			zk K
+			zv V
+		)
+		return zk, zv, false
+	}
+	return c.m.front()
+}
+
+// Resize changes the capacity, evicting least recently used entries if the
+// cache holds more than the new capacity. It returns the number of entries
+// evicted. A capacity below 1 is treated as 1.
+func (c *LRU[K, V]) Resize(capacity int) int {
+	if c == nil {
+		return 0
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	c.capacity = capacity
+	return c.evict()
+}
+
+// Len returns the number of cached entries.
+func (c *LRU[K, V]) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.m.Len()
+}
+
+// Cap returns the maximum number of entries the cache holds.
+func (c *LRU[K, V]) Cap() int {
+	if c == nil {
+		return 0
+	}
+	return max(c.capacity, 1)
+}
+
+// Clear removes all entries without calling the eviction callback.
+func (c *LRU[K, V]) Clear() {
+	if c == nil {
+		return
+	}
+	c.m.Clear()
+}
+
+// Keys returns the cached keys from most to least recently used.
+func (c *LRU[K, V]) Keys() []K {
+	if c == nil || c.m.Len() == 0 {
+		return nil
+	}
+	keys := make([]K, 0, c.m.Len())
+	for k := range c.m.Backward() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// All returns an iterator over entries from most to least recently used.
+// Iterating does not change recency.
+func (c *LRU[K, V]) All() iter.Seq2[K, V] {
+	if c == nil {
+		return func(func(K, V) bool) {}
+	}
+	return c.m.Backward()
+}
+
+// Backward returns an iterator over entries from least to most recently used.
+// Iterating does not change recency.
+func (c *LRU[K, V]) Backward() iter.Seq2[K, V] {
+	if c == nil {
+		return func(func(K, V) bool) {}
+	}
+	return c.m.All()
+}
+
+// evict removes least recently used entries until the capacity is respected
+// and returns how many were removed.
+func (c *LRU[K, V]) evict() int {
+	if c.capacity < 1 {
+		c.capacity = 1 // zero value; NewLRU and Resize clamp the same way
+	}
+	n := 0
+	for c.m.Len() > c.capacity {
+		k, v, _ := c.m.front()
+		c.m.Delete(k)
+		n++
+		if c.onEvict != nil {
+			c.onEvict(k, v)
+		}
+	}
+	return n
+}
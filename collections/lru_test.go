@@ -0,0 +1,128 @@
+package collections
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[string, int](2)
+	var evicted []string
+	c.SetOnEvict(func(k string, v int) { evicted = append(evicted, k) })
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("get a")
+	}
+	if !c.Set("c", 3) {
+		t.Fatalf("set should report eviction")
+	}
+	if c.Has("b") || !c.Has("a") || !c.Has("c") {
+		t.Fatalf("b should have been evicted, keys %v", c.Keys())
+	}
+	if !reflect.DeepEqual(evicted, []string{"b"}) {
+		t.Fatalf("evicted %v", evicted)
+	}
+
+	if c.Set("a", 10) {
+		t.Fatalf("updating a key must not evict")
+	}
+	if got := c.Keys(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Fatalf("keys %v", got)
+	}
+	if v, _ := c.Peek("a"); v != 10 {
+		t.Fatalf("peek %d", v)
+	}
+}
+
+func TestLRUPeekDoesNotRefresh(t *testing.T) {
+	c := NewLRU[int, int](2)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Peek(1)
+	c.Has(1)
+	c.Set(3, 3)
+	if c.Has(1) {
+		t.Fatalf("peek must not refresh recency")
+	}
+	if k, _, ok := c.Oldest(); !ok || k != 2 {
+		t.Fatalf("oldest %d", k)
+	}
+}
+
+func TestLRUResizeAndIteration(t *testing.T) {
+	c := NewLRU[int, string](5)
+	for i := 1; i <= 5; i++ {
+		c.Set(i, "")
+	}
+	c.Get(2)
+
+	var order []int
+	for k := range c.All() {
+		order = append(order, k)
+	}
+	if !reflect.DeepEqual(order, []int{2, 5, 4, 3, 1}) {
+		t.Fatalf("all %v", order)
+	}
+	order = order[:0]
+	for k := range c.Backward() {
+		order = append(order, k)
+	}
+	if !reflect.DeepEqual(order, []int{1, 3, 4, 5, 2}) {
+		t.Fatalf("backward %v", order)
+	}
+
+	var evicted []int
+	c.SetOnEvict(func(k int, _ string) { evicted = append(evicted, k) })
+	if n := c.Resize(2); n != 3 || c.Len() != 2 || c.Cap() != 2 {
+		t.Fatalf("resize evicted %d, len %d, cap %d", n, c.Len(), c.Cap())
+	}
+	if !reflect.DeepEqual(evicted, []int{1, 3, 4}) {
+		t.Fatalf("evicted %v", evicted)
+	}
+	if n := c.Resize(0); n != 1 || c.Cap() != 1 || !c.Has(2) {
+		t.Fatalf("resize below 1 should clamp, evicted %d", n)
+	}
+
+	if !c.Delete(2) || c.Len() != 0 || len(evicted) != 4 {
+		t.Fatalf("delete should not call the eviction callback")
+	}
+}
+
+func TestLRUNilSafety(t *testing.T) {
+	var c *LRU[string, int]
+	if c.Set("a", 1) || c.Has("a") || c.Len() != 0 || c.Cap() != 0 || c.Delete("a") {
+		t.Fatalf("nil cache should behave empty")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("nil get")
+	}
+	for range c.All() {
+		t.Fatalf("nil iteration")
+	}
+	c.Clear()
+}
+
+func TestLRUZeroValue(t *testing.T) {
+	var c LRU[string, int]
+	var evicted []string
+	c.SetOnEvict(func(k string, _ int) { evicted = append(evicted, k) })
+	if c.Cap() != 1 {
+		t.Fatalf("zero cache cap %d, want 1", c.Cap())
+	}
+	if c.Set("a", 1) || c.Len() != 1 || len(evicted) != 0 {
+		t.Fatalf("zero cache should keep its first entry, evicted %v", evicted)
+	}
+	if !c.Set("b", 2) || !reflect.DeepEqual(c.Keys(), []string{"b"}) || !reflect.DeepEqual(evicted, []string{"a"}) {
+		t.Fatalf("zero cache keys %v, evicted %v", c.Keys(), evicted)
+	}
+}
+
+func BenchmarkLRUSetGet(b *testing.B) {
+	c := NewLRU[int, int](1024)
+	for i := 0; i < b.N; i++ {
+		c.Set(i%2048, i)
+		c.Get(i % 1024)
+	}
+}
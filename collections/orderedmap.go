@@ -1,26 +1,89 @@
 package collections
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"iter"
+	"math"
+	"reflect"
+	"strconv"
 )
 
+// OrderedMap is a map that remembers the order in which keys were inserted.
+//
+// Entries live in a slab of nodes linked by int32 indices rather than in
+// individually allocated nodes, so inserting a key costs no allocation beyond
+// amortized slab and map growth, and slots freed by Delete are reused by
+// later inserts, oldest first. Set, Get and Delete are O(1) on average;
+// iteration is O(n). The zero value is an empty map ready to use.
 type OrderedMap[K comparable, V any] struct {
-	nodes  map[K]*orderedNode[K, V]
-	head   *orderedNode[K, V]
-	tail   *orderedNode[K, V]
-	length int
+	nodes    map[K]int32
+	slab     []orderedNode[K, V] // slab[0] is the list sentinel
+	freed    []freedSlot         // per-slot state of released slots
+	free     int32               // oldest free slot, linked through freed
+	freeTail int32               // newest free slot
+	length   int
+
+	// epoch changes whenever Clear or Compact renumbers the slab, which ends
+	// iterations and moves cursors off the list. mods changes on every
+	// change to the links, so an iteration whose loop body left the list
+	// alone can follow the link it read before yielding.
+	epoch uint32
+	mods  uint32
+
+	// tree is the positional index, parallel to slab, while indexed is set.
+	tree    []rankNode
+	root    int32
+	indexed bool
+
+	accessOrder  bool
+	removeEldest func(K, V) bool
 }
 
+// orderedNode is a slab slot. Links are slab indices; 0 is the sentinel,
+// whose next and prev are the head and tail of the list.
+//
+// Iterators and cursors never write to the map. Instead they remember the
+// generation of the slot they are on: gen is bumped when the slot is
+// allocated and when it is released, so it is odd while the slot holds an
+// entry. A released slot keeps its links, so a reader whose entry was
+// deleted can follow them back to the live list for as long as the slots on
+// the way have not been reused.
 type orderedNode[K comparable, V any] struct {
 	key   K
 	value V
-	prev  *orderedNode[K, V]
-	next  *orderedNode[K, V]
+	prev  int32
+	next  int32
+	gen   uint32
+}
+
+// freedSlot holds what a released slot needs beyond its node. It lives in a
+// separate slice to keep nodes small for iteration.
+type freedSlot struct {
+	prevGen  uint32 // generations of prev and next when the slot was released
+	nextGen  uint32
+	nextFree int32
 }
 
 func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
-	return &OrderedMap[K, V]{nodes: make(map[K]*orderedNode[K, V])}
+	return NewOrderedMapWithCapacity[K, V](0)
+}
+
+// NewOrderedMapWithCapacity creates an ordered map with room for capacity
+// entries, so that inserting up to that many keys does not allocate.
+func NewOrderedMapWithCapacity[K comparable, V any](capacity int) *OrderedMap[K, V] {
+	if capacity < 0 {
+		capacity = 0
+	}
+	m := &OrderedMap[K, V]{
+		nodes: make(map[K]int32, capacity),
+		slab:  make([]orderedNode[K, V], 1, capacity+1),
+	}
+	return m
 }
 
 func (m *OrderedMap[K, V]) ensure() {
@@ -28,43 +91,84 @@ func (m *OrderedMap[K, V]) ensure() {
 		return
 	}
 	if m.nodes == nil {
-		m.nodes = make(map[K]*orderedNode[K, V])
+		m.nodes = make(map[K]int32)
+	}
+	if len(m.slab) == 0 {
+		m.slab = make([]orderedNode[K, V], 1)
 	}
 }
 
-// Set inserts or updates the value for the key, preserving insertion order.
-func (m *OrderedMap[K, V]) Set(k K, v V) {
+// SetAccessOrder selects how entries are ordered. By default entries stay in
+// insertion order. When enabled, Get and Set also move the touched key to the
+// back, so iteration runs from least to most recently accessed, like Java's
+// LinkedHashMap with accessOrder=true. Has, Range, All and Backward never
+// count as access. Changing the mode does not reorder existing entries.
+func (m *OrderedMap[K, V]) SetAccessOrder(enabled bool) {
 	if m == nil {
 		return
 	}
-	m.ensure()
-	if node, ok := m.nodes[k]; ok {
-		node.value = v
+	m.accessOrder = enabled
+}
+
+// AccessOrder reports whether the map is in access-order mode.
+func (m *OrderedMap[K, V]) AccessOrder() bool {
+	return m != nil && m.accessOrder
+}
+
+// SetRemoveEldestFunc registers fn to be consulted after each insertion of a
+// new key by Set, InsertBefore or InsertAfter. fn receives the eldest entry,
+// the one at the front of the iteration order, and that entry is deleted if
+// fn returns true. Combined with SetAccessOrder this yields a bounded
+// recency-ordered map. Passing nil removes the hook.
+func (m *OrderedMap[K, V]) SetRemoveEldestFunc(fn func(key K, value V) bool) {
+	if m == nil {
+		return
+	}
+	m.removeEldest = fn
+}
+
+// Set inserts or updates the value for the key, preserving insertion order.
+// In access-order mode the key is also moved to the back.
+func (m *OrderedMap[K, V]) Set(k K, v V) {
+	if m == nil {
 		return
 	}
-	node := &orderedNode[K, V]{key: k, value: v}
-	if m.tail == nil {
-		m.head = node
-		m.tail = node
+	if i, added := m.put(k, v); added {
+		m.afterInsert()
 	} else {
-		m.tail.next = node
-		node.prev = m.tail
-		m.tail = node
+		m.touch(i)
+	}
+}
+
+// put inserts or updates the value for the key without counting an access or
+// consulting the remove-eldest hook. It returns the key's slot and whether
+// the key is new.
+func (m *OrderedMap[K, V]) put(k K, v V) (int32, bool) {
+	m.ensure()
+	if i, ok := m.nodes[k]; ok {
+		m.slab[i].value = v
+		return i, false
 	}
-	m.nodes[k] = node
+	i := m.alloc(k, v)
+	m.linkAfter(i, m.slab[0].prev)
+	m.nodes[k] = i
 	m.length++
+	return i, true
 }
 
+// Get returns the value for the key. In access-order mode a successful Get
+// moves the key to the back.
 func (m *OrderedMap[K, V]) Get(k K) (V, bool) {
 	var zero V
 	if m == nil || m.nodes == nil {
 		return zero, false
 	}
-	node, ok := m.nodes[k]
+	i, ok := m.nodes[k]
 	if !ok {
 		return zero, false
 	}
-	return node.value, true
+	m.touch(i)
+	return m.slab[i].value, true
 }
 
 func (m *OrderedMap[K, V]) Has(k K) bool {
@@ -75,37 +179,403 @@ func (m *OrderedMap[K, V]) Has(k K) bool {
 	return ok
 }
 
-// Delete removes the key if present.
+// Delete removes the key if present. Its slot is reused by later inserts.
 func (m *OrderedMap[K, V]) Delete(k K) bool {
 	if m == nil || m.nodes == nil {
 		return false
 	}
-	node, ok := m.nodes[k]
+	i, ok := m.nodes[k]
 	if !ok {
 		return false
 	}
-	if node.prev != nil {
-		node.prev.next = node.next
-	} else {
-		m.head = node.next
-	}
-	if node.next != nil {
-		node.next.prev = node.prev
-	} else {
-		m.tail = node.prev
-	}
+	m.unlink(i)
 	delete(m.nodes, k)
 	m.length--
+	m.release(i)
+	return true
+}
+
+// MoveToFront moves the key to the front of the iteration order.
+// It reports whether the key was present. Complexity: O(1).
+func (m *OrderedMap[K, V]) MoveToFront(k K) bool {
+	if m == nil || m.nodes == nil {
+		return false
+	}
+	i, ok := m.nodes[k]
+	if !ok {
+		return false
+	}
+	if i != m.slab[0].next {
+		m.unlink(i)
+		m.linkAfter(i, 0)
+	}
+	return true
+}
+
+// MoveToBack moves the key to the back of the iteration order.
+// It reports whether the key was present. Complexity: O(1).
+func (m *OrderedMap[K, V]) MoveToBack(k K) bool {
+	if m == nil || m.nodes == nil {
+		return false
+	}
+	i, ok := m.nodes[k]
+	if !ok {
+		return false
+	}
+	if i != m.slab[0].prev {
+		m.unlink(i)
+		m.linkAfter(i, m.slab[0].prev)
+	}
+	return true
+}
+
+// MoveBefore moves the key k to the position immediately before mark.
+// If either key is absent or k == mark, the map is not modified and
+// MoveBefore returns false. Complexity: O(1).
+func (m *OrderedMap[K, V]) MoveBefore(k, mark K) bool {
+	i, at, ok := m.movePair(k, mark)
+	if !ok {
+		return false
+	}
+	if m.slab[at].prev != i {
+		m.unlink(i)
+		m.linkAfter(i, m.slab[at].prev)
+	}
 	return true
 }
 
+// MoveAfter moves the key k to the position immediately after mark.
+// If either key is absent or k == mark, the map is not modified and
+// MoveAfter returns false. Complexity: O(1).
+func (m *OrderedMap[K, V]) MoveAfter(k, mark K) bool {
+	i, at, ok := m.movePair(k, mark)
+	if !ok {
+		return false
+	}
+	if m.slab[at].next != i {
+		m.unlink(i)
+		m.linkAfter(i, at)
+	}
+	return true
+}
+
+// InsertBefore adds a new key immediately before mark.
+// If k is already present or mark is absent, the map is not modified and
+// InsertBefore returns false. Complexity: O(1).
+func (m *OrderedMap[K, V]) InsertBefore(k K, v V, mark K) bool {
+	at, ok := m.insertMark(k, mark)
+	if !ok {
+		return false
+	}
+	i := m.alloc(k, v)
+	m.linkAfter(i, m.slab[at].prev)
+	m.nodes[k] = i
+	m.length++
+	m.afterInsert()
+	return true
+}
+
+// InsertAfter adds a new key immediately after mark.
+// If k is already present or mark is absent, the map is not modified and
+// InsertAfter returns false. Complexity: O(1).
+func (m *OrderedMap[K, V]) InsertAfter(k K, v V, mark K) bool {
+	at, ok := m.insertMark(k, mark)
+	if !ok {
+		return false
+	}
+	i := m.alloc(k, v)
+	m.linkAfter(i, at)
+	m.nodes[k] = i
+	m.length++
+	m.afterInsert()
+	return true
+}
+
+// Compact rewrites the slab so that entries occupy consecutive slots in
+// iteration order, dropping free slots and trimming excess capacity. It is
+// useful after deleting many keys, and also restores memory locality for
+// iteration. Compacting ends any iteration in progress and moves cursors off
+// the list. Complexity: O(n).
+func (m *OrderedMap[K, V]) Compact() {
+	if m == nil || len(m.slab) == 0 {
+		return
+	}
+	slab := make([]orderedNode[K, V], m.length+1)
+	j := int32(0)
+	for i := m.slab[0].next; i != 0; i = m.slab[i].next {
+		j++
+		n := &m.slab[i]
+		slab[j] = orderedNode[K, V]{key: n.key, value: n.value, prev: j - 1, next: j + 1, gen: 1}
+		m.nodes[n.key] = j
+	}
+	slab[0].next = min(1, j)
+	slab[0].prev = j
+	slab[j].next = 0
+	m.slab = slab
+	m.freed, m.free, m.freeTail = nil, 0, 0
+	m.epoch++
+	m.mods++
+	if m.indexed {
+		m.tree = nil
+		m.reindex()
+	}
+}
+
+// touch records an access to slot i, moving it to the back in access-order
+// mode.
+func (m *OrderedMap[K, V]) touch(i int32) {
+	if !m.accessOrder || i == m.slab[0].prev {
+		return
+	}
+	m.unlink(i)
+	m.linkAfter(i, m.slab[0].prev)
+}
+
+// afterInsert consults the remove-eldest hook after a new key was added and
+// reports whether it removed the eldest entry.
+func (m *OrderedMap[K, V]) afterInsert() bool {
+	if m.removeEldest == nil || m.length == 0 {
+		return false
+	}
+	if eldest := m.slab[m.slab[0].next]; m.removeEldest(eldest.key, eldest.value) {
+		m.Delete(eldest.key)
+		return true
+	}
+	return false
+}
+
+// front returns the first entry in iteration order.
+func (m *OrderedMap[K, V]) front() (K, V, bool) {
+	if m == nil || m.length == 0 {
+		var (
+			zk K
+			zv V
+		)
+		return zk, zv, false
+	}
+	n := &m.slab[m.slab[0].next]
+	return n.key, n.value, true
+}
+
+// At returns the entry at position i in iteration order.
+// It panics if i is out of range.
+//
+// At is O(log n) while the map is indexed (see SetIndexed) and otherwise
+// walks from the nearer end of the list in O(n).
+func (m *OrderedMap[K, V]) At(i int) (K, V) {
+	if i < 0 || i >= m.Len() {
+		panic(fmt.Sprintf("collections: OrderedMap.At index %d out of range [0:%d]", i, m.Len()))
+	}
+	n := &m.slab[m.slotAt(i)]
+	return n.key, n.value
+}
+
+// IndexOf returns the position of the key in iteration order, or -1 if the
+// key is absent. It is O(log n) while the map is indexed and O(n) otherwise.
+func (m *OrderedMap[K, V]) IndexOf(k K) int {
+	if m == nil || m.nodes == nil {
+		return -1
+	}
+	i, ok := m.nodes[k]
+	if !ok {
+		return -1
+	}
+	if m.indexed {
+		return m.rank(i)
+	}
+	pos := 0
+	for j := m.slab[0].next; j != i; j = m.slab[j].next {
+		pos++
+	}
+	return pos
+}
+
+// Slice returns an iterator over the entries at positions [from, to) in
+// iteration order. Bounds are clamped to [0, Len()], which makes Slice
+// convenient for pagination. Locating the first entry costs the same as At;
+// each further entry is O(1). Mutation during iteration follows the rules
+// described on All; positions are fixed when iteration starts.
+func (m *OrderedMap[K, V]) Slice(from, to int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		from, to := max(from, 0), min(to, m.Len())
+		if from >= to {
+			return
+		}
+		m.walk(m.slotAt(from), to-from, true, yield)
+	}
+}
+
+// movePair looks up the slots for a move of k relative to mark.
+func (m *OrderedMap[K, V]) movePair(k, mark K) (i, at int32, ok bool) {
+	if m == nil || m.nodes == nil || k == mark {
+		return 0, 0, false
+	}
+	if i, ok = m.nodes[k]; !ok {
+		return 0, 0, false
+	}
+	if at, ok = m.nodes[mark]; !ok {
+		return 0, 0, false
+	}
+	return i, at, true
+}
+
+// insertMark looks up the mark slot for inserting the new key k.
+func (m *OrderedMap[K, V]) insertMark(k, mark K) (int32, bool) {
+	if m == nil || m.nodes == nil {
+		return 0, false
+	}
+	if _, exists := m.nodes[k]; exists {
+		return 0, false
+	}
+	at, ok := m.nodes[mark]
+	return at, ok
+}
+
+// alloc stores a detached entry in the oldest free slot, growing the slab if
+// there is none. Reusing the oldest slot keeps recently deleted slots, which
+// readers may still be stepping off, intact for as long as possible.
+func (m *OrderedMap[K, V]) alloc(k K, v V) int32 {
+	if i := m.free; i != 0 {
+		n := &m.slab[i]
+		m.free = m.freed[i].nextFree
+		if m.free == 0 {
+			m.freeTail = 0
+		}
+		n.key, n.value = k, v
+		n.gen++
+		return i
+	}
+	if len(m.slab) > math.MaxInt32 {
+		panic("collections: OrderedMap exceeds maximum size")
+	}
+	m.slab = append(m.slab, orderedNode[K, V]{key: k, value: v, gen: 1})
+	return int32(len(m.slab) - 1)
+}
+
+// release appends an unlinked slot to the free list. The slot keeps its
+// links so that readers positioned on it can find the live list again.
+func (m *OrderedMap[K, V]) release(i int32) {
+	s := m.slab
+	n := &s[i]
+	var (
+		zk K
+		zv V
+	)
+	n.key, n.value = zk, zv
+	if len(m.freed) <= int(i) {
+		m.freed = append(m.freed, make([]freedSlot, len(s)-len(m.freed))...)
+	}
+	m.freed[i] = freedSlot{prevGen: s[n.prev].gen, nextGen: s[n.next].gen}
+	n.gen++
+	if m.freeTail != 0 {
+		m.freed[m.freeTail].nextFree = i
+	} else {
+		m.free = i
+	}
+	m.freeTail = i
+}
+
+// live returns the first live slot at or beyond slot i in the given
+// direction, where gen is the generation slot i had when it was reached; 0
+// means the end of the list. Released slots are stepped over through the
+// links they kept. It reports false if a slot on the way has been reused, so
+// the position is lost.
+func (m *OrderedMap[K, V]) live(i int32, gen uint32, forward bool) (int32, bool) {
+	s := m.slab
+	for i != 0 {
+		switch s[i].gen {
+		case gen:
+			return i, true
+		case gen + 1:
+			if forward {
+				i, gen = s[i].next, m.freed[i].nextGen
+			} else {
+				i, gen = s[i].prev, m.freed[i].prevGen
+			}
+		default:
+			return 0, false
+		}
+	}
+	return 0, true
+}
+
+// walk yields up to n entries starting at slot i and moving forward or
+// backward, without writing to the map.
+func (m *OrderedMap[K, V]) walk(i int32, n int, forward bool, yield func(K, V) bool) {
+	s, epoch, mods := m.slab, m.epoch, m.mods
+	for ; i != 0 && n > 0; n-- {
+		e := &s[i]
+		gen, succ := e.gen, e.next
+		if !forward {
+			succ = e.prev
+		}
+		succGen := s[succ].gen
+		if !yield(e.key, e.value) {
+			return
+		}
+		if m.mods != mods {
+			s, mods = m.slab, m.mods
+			succ = m.resume(i, gen, succ, succGen, forward, epoch)
+		}
+		i = succ
+	}
+}
+
+// resume returns the slot an iteration continues from after the loop body
+// changed the list, or 0 if it has to stop. The iteration notes the
+// neighbour it would move to before each yield, so that if the loop body
+// deletes the current entry and its slot is reused at once, it can still
+// continue from that neighbour.
+func (m *OrderedMap[K, V]) resume(i int32, gen uint32, succ int32, succGen uint32, forward bool, epoch uint32) int32 {
+	switch {
+	case m.epoch != epoch:
+		return 0
+	case m.slab[i].gen != gen:
+	case forward:
+		return m.slab[i].next
+	default:
+		return m.slab[i].prev
+	}
+	i, ok := m.live(i, gen, forward)
+	if !ok {
+		i, _ = m.live(succ, succGen, forward)
+	}
+	return i
+}
+
+// linkAfter links the detached slot i after at, where at == 0 links it at
+// the front.
+func (m *OrderedMap[K, V]) linkAfter(i, at int32) {
+	if m.indexed {
+		m.treeInsert(i, at)
+	}
+	s := m.slab
+	next := s[at].next
+	s[i].prev = at
+	s[i].next = next
+	s[at].next = i
+	s[next].prev = i
+	m.mods++
+}
+
+// unlink detaches slot i from the list. The slot's own links are left intact.
+func (m *OrderedMap[K, V]) unlink(i int32) {
+	if m.indexed {
+		m.treeRemove(i)
+	}
+	s := m.slab
+	s[s[i].prev].next = s[i].next
+	s[s[i].next].prev = s[i].prev
+	m.mods++
+}
+
 func (m *OrderedMap[K, V]) Keys() []K {
 	if m == nil || m.length == 0 {
 		return nil
 	}
 	keys := make([]K, 0, m.length)
-	for n := m.head; n != nil; n = n.next {
-		keys = append(keys, n.key)
+	for i := m.slab[0].next; i != 0; i = m.slab[i].next {
+		keys = append(keys, m.slab[i].key)
 	}
 	return keys
 }
@@ -115,60 +585,90 @@ func (m *OrderedMap[K, V]) Values() []V {
 		return nil
 	}
 	values := make([]V, 0, m.length)
-	for n := m.head; n != nil; n = n.next {
-		values = append(values, n.value)
+	for i := m.slab[0].next; i != 0; i = m.slab[i].next {
+		values = append(values, m.slab[i].value)
 	}
 	return values
 }
 
 // Range walks the map in insertion order until fn returns false.
+// Mutation during Range follows the rules described on All.
 func (m *OrderedMap[K, V]) Range(fn func(K, V) bool) {
-	if m == nil {
-		return
-	}
-	for n := m.head; n != nil; n = n.next {
-		if !fn(n.key, n.value) {
-			return
-		}
-	}
+	m.All()(fn)
 }
 
 // RangeReverse walks the map from newest to oldest until fn returns false.
+// Mutation during RangeReverse follows the rules described on All.
 func (m *OrderedMap[K, V]) RangeReverse(fn func(K, V) bool) {
-	if m == nil {
-		return
-	}
-	for n := m.tail; n != nil; n = n.prev {
-		if !fn(n.key, n.value) {
-			return
-		}
-	}
+	m.Backward()(fn)
 }
 
 // All returns an iterator over key-value pairs in insertion order.
+//
+// Iteration only reads the map, so any number of goroutines may iterate at
+// once as long as none modifies the map. The loop body itself may modify it:
+//
+//   - An entry deleted before it is reached is not produced.
+//   - Deleting the current entry is safe; iteration continues with the live
+//     entry that followed it. Clearing or compacting the map ends the
+//     iteration.
+//   - A value updated before its entry is reached is produced with the new
+//     value.
+//   - A key inserted during iteration may or may not be produced.
+//   - Moving entries during iteration, including by Get or Set in
+//     access-order mode or by sorting or reversing the map, may cause
+//     entries to be produced twice or skipped; moving the current entry
+//     continues iteration from its new position.
+//
+// If no entries are moved, every entry present when iteration starts and not
+// deleted before it is reached is produced exactly once, with one exception:
+// deleted entries' slots are reused by later inserts, and if a single step
+// of the loop deletes both the current entry and the one after it and then
+// inserts enough keys to reuse both slots, iteration ends early. Edits made
+// through a Cursor are not subject to this.
 func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		if m == nil {
+		if m == nil || m.length == 0 {
 			return
 		}
-		for n := m.head; n != nil; n = n.next {
-			if !yield(n.key, n.value) {
+		// Written out rather than calling walk so that the loop inlines.
+		s, epoch, mods := m.slab, m.epoch, m.mods
+		for i := s[0].next; i != 0; {
+			e := &s[i]
+			gen, succ := e.gen, e.next
+			succGen := s[succ].gen
+			if !yield(e.key, e.value) {
 				return
 			}
+			if m.mods != mods {
+				s, mods = m.slab, m.mods
+				succ = m.resume(i, gen, succ, succGen, true, epoch)
+			}
+			i = succ
 		}
 	}
 }
 
 // Backward returns an iterator over key-value pairs in reverse insertion order.
+// Mutation during iteration follows the rules described on All, mirrored.
 func (m *OrderedMap[K, V]) Backward() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		if m == nil {
+		if m == nil || m.length == 0 {
 			return
 		}
-		for n := m.tail; n != nil; n = n.prev {
-			if !yield(n.key, n.value) {
+		s, epoch, mods := m.slab, m.epoch, m.mods
+		for i := s[0].prev; i != 0; {
+			e := &s[i]
+			gen, succ := e.gen, e.prev
+			succGen := s[succ].gen
+			if !yield(e.key, e.value) {
 				return
 			}
+			if m.mods != mods {
+				s, mods = m.slab, m.mods
+				succ = m.resume(i, gen, succ, succGen, false, epoch)
+			}
+			i = succ
 		}
 	}
 }
@@ -190,104 +690,231 @@ func (m *OrderedMap[K, V]) ValuesSlice() []V {
 	return m.Values()
 }
 
+// Clear removes all entries. The slab keeps its capacity for reuse; call
+// Compact afterwards to release it.
 func (m *OrderedMap[K, V]) Clear() {
 	if m == nil || m.nodes == nil {
 		return
 	}
-	m.nodes = make(map[K]*orderedNode[K, V])
-	m.head = nil
-	m.tail = nil
+	clear(m.nodes)
 	m.length = 0
+	clear(m.slab)
+	m.slab = m.slab[:1]
+	m.free, m.freeTail = 0, 0
+	m.epoch++
+	m.mods++
+	m.root = 0
 }
 
 // MarshalJSON implements the json.Marshaler interface.
-// It serializes the OrderedMap as a JSON object, preserving the insertion order
-// (though standard JSON parsers may not respect it).
+//
+// The map is encoded as a JSON object whose members appear in iteration
+// order. Keys follow the encoding/json rules for map keys: string kinds are
+// used directly, types implementing encoding.TextMarshaler are marshaled as
+// text, and integer kinds are formatted in base 10; any other key type is an
+// error. Output is compact and HTML-escaped, matching json.Marshal.
 func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
 	if m == nil {
 		return []byte("null"), nil
 	}
-	// We build the object manually to control order.
-	// Note: This assumes keys are simple strings or can be stringified.
-	// For complex keys, standard Go map behaviors apply.
-	// For simplicity in this generic implementation, we use a map if keys are strings,
-	// but standard library usually iterates maps randomly.
-	// To strictly preserve order in output, we must construct the byte slice or use a struct?
-	// Actually, Go's json.Marshal sorts map keys.
-	// To preserve order, we must output as a JSON object { "k1": v1, "k2": v2 } manually
-	// OR output as a list of pairs [ {"Key": k1, "Value": v1}, ... ].
-	//
-	// Most "Ordered Map" implementations in other langs serialize as an object.
-	// Let's implement a manual write to ensure order in the byte stream.
-
-	var buf []byte
-	buf = append(buf, '{')
-	i := 0
+	if err := checkJSONMarshalKeyType(reflect.TypeFor[K]()); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
 	for k, v := range m.All() {
-		if i > 0 {
-			buf = append(buf, ',')
+		if !first {
+			buf.WriteByte(',')
 		}
-		// Marshal Key
-		keyBytes, err := json.Marshal(k)
+		first = false
+		name, err := encodeJSONKey(k)
 		if err != nil {
 			return nil, err
 		}
-		// If key was not a string, we might need to conform to JSON object key rules (must be string).
-		// json.Marshal will quote strings. If K is int, it outputs number. JSON keys MUST be strings.
-		// Detailed generic handling is complex.
-		// Fallback: We'll create a temporary map to check how json.Marshal handles K.
-		// Better approach for standard lib quality:
-		// Just treat it as an object {k:v}. Clients rely on their parser to keep order.
-		// We iterate in order, so we emit in order.
-
-		// Note: Simply calling json.Marshal on the map field would lose order.
-
-		// Tricky: K might be int. JSON object keys must be strings.
-		// Go's json package handles map[int]T by converting int to string.
-		// We replicate that check? Or just delegate to json.Marshal for the key.
-		// Let's assume K is string-compatible or user accepts valid JSON key rules.
-
-		// If K is not string, we strip quotes? No, JSON keys must be quoted strings.
-		// We will marshal the key, then ensure it is a string.
-
-		if len(keyBytes) > 0 && keyBytes[0] != '"' {
-			// Convert non-string key to string for JSON validity
-			// e.g. 123 -> "123"
-			keyBytes = []byte("\"" + string(keyBytes) + "\"")
+		b, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
 		}
-
-		buf = append(buf, keyBytes...)
-		buf = append(buf, ':')
-		valBytes, err := json.Marshal(v)
+		buf.Write(b)
+		buf.WriteByte(':')
+		b, err = json.Marshal(v)
 		if err != nil {
 			return nil, err
 		}
-		buf = append(buf, valBytes...)
-		i++
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// checkJSONMarshalKeyType reports whether encoding/json could encode a map
+// key of type kt.
+func checkJSONMarshalKeyType(kt reflect.Type) error {
+	switch kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+	}
+	if kt.Implements(textMarshalerType) {
+		return nil
+	}
+	return &json.UnsupportedTypeError{Type: kt}
+}
+
+// encodeJSONKey returns the object member name for k, mirroring encoding/json:
+// string kinds take precedence, then encoding.TextMarshaler, then integers.
+func encodeJSONKey[K comparable](k K) (string, error) {
+	kv := reflect.ValueOf(&k).Elem()
+	if kv.Kind() == reflect.String {
+		return kv.String(), nil
+	}
+	if tm, ok := any(k).(encoding.TextMarshaler); ok {
+		if kv.Kind() == reflect.Pointer && kv.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch kv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(kv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(kv.Uint(), 10), nil
 	}
-	buf = append(buf, '}')
-	return buf, nil
+	return "", &json.UnsupportedTypeError{Type: kv.Type()}
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
+//
+// The JSON object is decoded token by token, so keys are inserted in the order
+// they appear in the document. Any existing entries are discarded first. When
+// a key appears more than once, the last value wins but the key keeps the
+// position of its first occurrence, also in access-order mode. Decoding does
+// not count as access, and the remove-eldest hook is consulted only once the
+// object has been read, at most once per decoded key. Keys are converted using the same rules
+// encoding/json applies to map keys: K must be a string kind, an integer kind,
+// or implement encoding.TextUnmarshaler. A JSON null leaves the map unchanged.
 func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
-	// Unmarshaling into an ordered structure is difficult because json.Unmarshal
-	// into a map[K]V loses order before we see it.
-	// We would need a custom decoder.
-	// For now, we fallback to standard map unmarshal, effectively losing order from input.
-	// This is a known limitation unless we write a full parser.
-	// We will populate the map, order will be random (or specific to Go's map iteration).
-
-	// To support keeping order from JSON, we'd need to parse the token stream.
-	// For V1, let's just support loading data.
-
-	tmp := make(map[K]V)
-	if err := json.Unmarshal(data, &tmp); err != nil {
+	if m == nil {
+		return errors.New("collections: UnmarshalJSON on nil *OrderedMap")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return &json.UnmarshalTypeError{Value: jsonTokenKind(tok), Type: reflect.TypeFor[*OrderedMap[K, V]]()}
+	}
+	if err := checkJSONKeyType(reflect.TypeFor[K]()); err != nil {
 		return err
 	}
+
 	m.Clear()
-	for k, v := range tmp {
-		m.Set(k, v)
+	added := 0
+	defer func() {
+		for added > 0 && m.afterInsert() {
+			added--
+		}
+	}()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("collections: unexpected object key token %v", tok)
+		}
+		k, err := decodeJSONKey[K](key)
+		if err != nil {
+			return err
+		}
+		var v V
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if _, ok := m.put(k, v); ok {
+			added++
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("collections: invalid data after top-level JSON object")
 	}
 	return nil
 }
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// checkJSONKeyType reports whether encoding/json could decode object keys
+// into a map key of type kt.
+func checkJSONKeyType(kt reflect.Type) error {
+	switch kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+	}
+	if reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+		return nil
+	}
+	return &json.UnmarshalTypeError{Value: "object", Type: kt}
+}
+
+// decodeJSONKey converts a JSON object key to K, mirroring encoding/json:
+// encoding.TextUnmarshaler takes precedence, then string kinds, then base-10
+// integers with overflow checking.
+func decodeJSONKey[K comparable](s string) (K, error) {
+	var k K
+	kt := reflect.TypeFor[K]()
+	if reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+		err := any(&k).(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		return k, err
+	}
+	kv := reflect.ValueOf(&k).Elem()
+	switch kt.Kind() {
+	case reflect.String:
+		kv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || kv.OverflowInt(n) {
+			return k, &json.UnmarshalTypeError{Value: "number " + s, Type: kt}
+		}
+		kv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || kv.OverflowUint(n) {
+			return k, &json.UnmarshalTypeError{Value: "number " + s, Type: kt}
+		}
+		kv.SetUint(n)
+	default:
+		return k, &json.UnmarshalTypeError{Value: "object", Type: kt}
+	}
+	return k, nil
+}
+
+// jsonTokenKind describes a json.Token the way encoding/json names values in
+// UnmarshalTypeError.
+func jsonTokenKind(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	}
+	return "value"
+}
@@ -0,0 +1,147 @@
+package collections
+
+import "testing"
+
+// ptrOrderedMap is the previous OrderedMap layout: one heap-allocated node per
+// entry linked by pointers. It is kept here as a benchmark baseline.
+type ptrOrderedMap[K comparable, V any] struct {
+	nodes      map[K]*ptrNode[K, V]
+	head, tail *ptrNode[K, V]
+}
+
+type ptrNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *ptrNode[K, V]
+}
+
+// newPtrOrderedMap sizes the index map like NewOrderedMapWithCapacity; the
+// nodes themselves cannot be preallocated.
+func newPtrOrderedMap[K comparable, V any](capacity int) *ptrOrderedMap[K, V] {
+	return &ptrOrderedMap[K, V]{nodes: make(map[K]*ptrNode[K, V], capacity)}
+}
+
+func (m *ptrOrderedMap[K, V]) Set(k K, v V) {
+	if n, ok := m.nodes[k]; ok {
+		n.value = v
+		return
+	}
+	n := &ptrNode[K, V]{key: k, value: v, prev: m.tail}
+	if m.tail == nil {
+		m.head = n
+	} else {
+		m.tail.next = n
+	}
+	m.tail = n
+	m.nodes[k] = n
+}
+
+func (m *ptrOrderedMap[K, V]) Delete(k K) {
+	n, ok := m.nodes[k]
+	if !ok {
+		return
+	}
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		m.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	delete(m.nodes, k)
+}
+
+func (m *ptrOrderedMap[K, V]) All(yield func(K, V) bool) {
+	for n := m.head; n != nil; n = n.next {
+		if !yield(n.key, n.value) {
+			return
+		}
+	}
+}
+
+const benchEntries = 100_000
+
+func BenchmarkOrderedMapSet(b *testing.B) {
+	b.Run("slab", func(b *testing.B) {
+		b.ReportAllocs()
+		m := NewOrderedMapWithCapacity[int, int](benchEntries)
+		for i := 0; i < b.N; i++ {
+			if i%benchEntries == 0 {
+				m.Clear()
+			}
+			m.Set(i, i)
+		}
+	})
+	b.Run("pointer", func(b *testing.B) {
+		b.ReportAllocs()
+		m := newPtrOrderedMap[int, int](benchEntries)
+		for i := 0; i < b.N; i++ {
+			if i%benchEntries == 0 {
+				clear(m.nodes)
+				m.head, m.tail = nil, nil
+			}
+			m.Set(i, i)
+		}
+	})
+}
+
+// BenchmarkOrderedMapChurn keeps the map at a steady size, deleting the
+// oldest key for every insert.
+func BenchmarkOrderedMapChurn(b *testing.B) {
+	b.Run("slab", func(b *testing.B) {
+		b.ReportAllocs()
+		m := NewOrderedMap[int, int]()
+		for i := 0; i < benchEntries; i++ {
+			m.Set(i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Delete(i)
+			m.Set(i+benchEntries, i)
+		}
+	})
+	b.Run("pointer", func(b *testing.B) {
+		b.ReportAllocs()
+		m := newPtrOrderedMap[int, int](0)
+		for i := 0; i < benchEntries; i++ {
+			m.Set(i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Delete(i)
+			m.Set(i+benchEntries, i)
+		}
+	})
+}
+
+func BenchmarkOrderedMapIterate(b *testing.B) {
+	b.Run("slab", func(b *testing.B) {
+		m := NewOrderedMap[int, int]()
+		for i := 0; i < benchEntries; i++ {
+			m.Set(i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			for _, v := range m.All() {
+				sum += v
+			}
+		}
+	})
+	b.Run("pointer", func(b *testing.B) {
+		m := newPtrOrderedMap[int, int](0)
+		for i := 0; i < benchEntries; i++ {
+			m.Set(i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			for _, v := range m.All {
+				sum += v
+			}
+		}
+	})
+}
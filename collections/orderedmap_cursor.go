@@ -0,0 +1,226 @@
+package collections
+
+// Cursor is a position in an OrderedMap from which the map can be walked and
+// edited in place.
+//
+// A cursor is either on an entry, between two entries after its entry was
+// deleted, or off the list. Off the list, Next moves to the front and Prev to
+// the back, so a fresh cursor can drive a loop:
+//
+//	for c := m.Cursor(); c.Next(); {
+//	    if drop(c.Key()) {
+//	        c.Delete()
+//	    }
+//	}
+//
+// A cursor stays usable across deletions, whether made through the cursor or
+// directly on the map: after its entry is deleted, Valid reports false and
+// Next and Prev move to the live entries that surrounded it. If its entry is
+// moved, the cursor moves with it. Cursor methods do not count as access in
+// access-order mode.
+//
+// A cursor only reads the map until it is used to edit it, and holds nothing
+// that needs releasing: an abandoned cursor does not keep deleted slots from
+// being reused or stop Compact, and Close is optional. In exchange, a cursor
+// whose entry was deleted directly on the map loses its place if that slot
+// is reused before the cursor moves again, and is then off the list; edits
+// made through the cursor itself never lose its place. Clear and Compact
+// also move cursors off the list.
+type Cursor[K comparable, V any] struct {
+	m     *OrderedMap[K, V]
+	cur   int32  // slot of the current entry, or 0 when not on one
+	gen   uint32 // generation of cur when the cursor moved there
+	epoch uint32 // map epoch when the cursor last moved
+
+	// Between entries, the cursor sits after prev and before next, each
+	// recorded with its generation.
+	between          bool
+	prev, next       int32
+	prevGen, nextGen uint32
+}
+
+// Cursor returns a new cursor positioned off the list.
+func (m *OrderedMap[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{m: m}
+}
+
+// Valid reports whether the cursor is on a live entry.
+func (c *Cursor[K, V]) Valid() bool {
+	return c.cur != 0 && c.epoch == c.m.epoch && c.m.slab[c.cur].gen == c.gen
+}
+
+// Key returns the key of the current entry, or the zero value if the cursor
+// is not Valid.
+func (c *Cursor[K, V]) Key() K {
+	if !c.Valid() {
+		var zero K
+		return zero
+	}
+	return c.m.slab[c.cur].key
+}
+
+// Value returns the value of the current entry, or the zero value if the
+// cursor is not Valid.
+func (c *Cursor[K, V]) Value() V {
+	if !c.Valid() {
+		var zero V
+		return zero
+	}
+	return c.m.slab[c.cur].value
+}
+
+// SetValue replaces the value of the current entry without changing its
+// position. It reports whether the cursor was Valid.
+func (c *Cursor[K, V]) SetValue(v V) bool {
+	if !c.Valid() {
+		return false
+	}
+	c.m.slab[c.cur].value = v
+	return true
+}
+
+// Front moves the cursor to the first entry and reports whether there is one.
+func (c *Cursor[K, V]) Front() bool {
+	if !c.ready() {
+		return false
+	}
+	return c.moveTo(c.m.slab[0].next)
+}
+
+// Back moves the cursor to the last entry and reports whether there is one.
+func (c *Cursor[K, V]) Back() bool {
+	if !c.ready() {
+		return false
+	}
+	return c.moveTo(c.m.slab[0].prev)
+}
+
+// Seek moves the cursor to the key. If the key is absent the cursor is moved
+// off the list and Seek returns false.
+func (c *Cursor[K, V]) Seek(k K) bool {
+	if !c.ready() {
+		return false
+	}
+	return c.moveTo(c.m.nodes[k])
+}
+
+// Next moves the cursor to the following entry, or to the front if it is off
+// the list. It returns false when it moves past the back.
+func (c *Cursor[K, V]) Next() bool {
+	if !c.ready() {
+		return false
+	}
+	return c.moveTo(c.step(true))
+}
+
+// Prev moves the cursor to the preceding entry, or to the back if it is off
+// the list. It returns false when it moves past the front.
+func (c *Cursor[K, V]) Prev() bool {
+	if !c.ready() {
+		return false
+	}
+	return c.moveTo(c.step(false))
+}
+
+// Delete removes the current entry from the map. The cursor stays between the
+// neighbouring entries, so Next and Prev continue from there. It reports
+// whether the cursor was Valid.
+func (c *Cursor[K, V]) Delete() bool {
+	if !c.Valid() {
+		return false
+	}
+	m := c.m
+	n := &m.slab[c.cur]
+	c.setBetween(n.prev, m.slab[n.prev].gen, n.next, m.slab[n.next].gen)
+	return m.Delete(n.key)
+}
+
+// InsertAfter adds a new key immediately after the cursor's position without
+// moving the cursor. If the cursor is off the list the key becomes the first
+// entry; if its entry was deleted the key is inserted where that entry was.
+// InsertAfter returns false and leaves the map unchanged if k is present.
+func (c *Cursor[K, V]) InsertAfter(k K, v V) bool {
+	if c.m == nil {
+		return false
+	}
+	m := c.m
+	m.ensure()
+	if _, exists := m.nodes[k]; exists {
+		return false
+	}
+	c.settle()
+	at := c.cur
+	if c.between {
+		// A lost predecessor degrades to inserting at the front.
+		at, _ = m.live(c.prev, c.prevGen, false)
+	}
+	i := m.alloc(k, v)
+	m.linkAfter(i, at)
+	if c.between {
+		c.next, c.nextGen = i, m.slab[i].gen
+	}
+	m.nodes[k] = i
+	m.length++
+	m.afterInsert()
+	return true
+}
+
+// Close moves the cursor off the list. Cursors hold no resources, so Close
+// is never required.
+func (c *Cursor[K, V]) Close() {
+	c.cur, c.between = 0, false
+}
+
+// ready reports whether the map has storage the cursor can walk.
+func (c *Cursor[K, V]) ready() bool {
+	return c.m != nil && len(c.m.slab) > 0
+}
+
+// settle brings the cursor's position up to date with the map: a cursor
+// whose entry has been deleted moves between the entries the slot was linked
+// to, and a cursor whose position has been lost, because the slab was
+// renumbered or the slot was reused, moves off the list.
+func (c *Cursor[K, V]) settle() {
+	m := c.m
+	switch {
+	case c.epoch != m.epoch:
+		c.cur, c.between = 0, false
+	case c.cur == 0 || m.slab[c.cur].gen == c.gen:
+	case m.slab[c.cur].gen == c.gen+1:
+		n, f := &m.slab[c.cur], &m.freed[c.cur]
+		c.setBetween(n.prev, f.prevGen, n.next, f.nextGen)
+	default:
+		c.cur = 0
+	}
+}
+
+func (c *Cursor[K, V]) setBetween(prev int32, prevGen uint32, next int32, nextGen uint32) {
+	c.cur, c.between = 0, true
+	c.prev, c.prevGen, c.next, c.nextGen = prev, prevGen, next, nextGen
+}
+
+// step returns the slot Next or Prev should move to, 0 meaning past the end.
+func (c *Cursor[K, V]) step(forward bool) int32 {
+	c.settle()
+	m := c.m
+	var i int32
+	switch {
+	case c.between && forward:
+		i, _ = m.live(c.next, c.nextGen, true)
+	case c.between:
+		i, _ = m.live(c.prev, c.prevGen, false)
+	case forward:
+		i = m.slab[c.cur].next
+	default:
+		i = m.slab[c.cur].prev
+	}
+	return i
+}
+
+// moveTo puts the cursor on slot i and reports whether i is an entry rather
+// than the sentinel.
+func (c *Cursor[K, V]) moveTo(i int32) bool {
+	c.cur, c.between = i, false
+	c.gen, c.epoch = c.m.slab[i].gen, c.m.epoch
+	return i != 0
+}
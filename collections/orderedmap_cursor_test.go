@@ -0,0 +1,220 @@
+package collections
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCursorWalk(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for i, k := range []string{"a", "b", "c"} {
+		m.Set(k, i)
+	}
+	c := m.Cursor()
+	if c.Valid() || c.Key() != "" {
+		t.Fatalf("fresh cursor should be off the list")
+	}
+	var keys []string
+	for c.Next() {
+		keys = append(keys, c.Key())
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) || c.Valid() {
+		t.Fatalf("forward walk %v", keys)
+	}
+	keys = keys[:0]
+	for c.Prev() {
+		keys = append(keys, c.Key())
+	}
+	if !reflect.DeepEqual(keys, []string{"c", "b", "a"}) {
+		t.Fatalf("backward walk %v", keys)
+	}
+
+	if !c.Seek("b") || c.Value() != 1 || !c.SetValue(10) {
+		t.Fatalf("seek b")
+	}
+	if v, _ := m.Get("b"); v != 10 {
+		t.Fatalf("set value through cursor")
+	}
+	if c.Seek("missing") || c.Valid() || c.SetValue(1) {
+		t.Fatalf("seek to a missing key should leave the cursor off the list")
+	}
+	if !c.Back() || c.Key() != "c" || !c.Front() || c.Key() != "a" {
+		t.Fatalf("front/back")
+	}
+	c.Close()
+	if c.Valid() {
+		t.Fatalf("closed cursor should be off the list")
+	}
+}
+
+func TestCursorDelete(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+	for c := m.Cursor(); c.Next(); {
+		if c.Key()%3 != 0 {
+			if !c.Delete() || c.Valid() || c.Delete() {
+				t.Fatalf("delete through cursor")
+			}
+		}
+	}
+	checkOrder(t, m, 0, 3, 6, 9)
+
+	c := m.Cursor()
+	c.Seek(6)
+	c.Delete()
+	if !c.Prev() || c.Key() != 3 {
+		t.Fatalf("prev after delete should land on the preceding entry")
+	}
+	c.Seek(3)
+	c.Delete()
+	m.Delete(0) // neighbours deleted behind the cursor's back
+	m.Delete(9)
+	if c.Next() || c.Prev() {
+		t.Fatalf("cursor should see the map as empty")
+	}
+}
+
+func TestCursorInsertAfter(t *testing.T) {
+	var m OrderedMap[string, int]
+	c := m.Cursor()
+	if !c.InsertAfter("b", 2) || !c.InsertAfter("a", 1) {
+		t.Fatalf("insert off the list should go to the front")
+	}
+	checkOrder(t, &m, "a", "b")
+	c.Seek("b")
+	if !c.InsertAfter("d", 4) || c.Key() != "b" {
+		t.Fatalf("insert after should not move the cursor")
+	}
+	if c.InsertAfter("a", 9) {
+		t.Fatalf("insert of an existing key should fail")
+	}
+	c.Delete()
+	if !c.InsertAfter("c", 3) || !c.Next() || c.Key() != "c" {
+		t.Fatalf("insert at a deleted position should be reached by Next")
+	}
+	checkOrder(t, &m, "a", "c", "d")
+
+	m.Clear()
+	if c.Next() || c.Valid() {
+		t.Fatalf("cursor should run off a cleared map")
+	}
+	m.Set("z", 0)
+	if !c.Next() || c.Key() != "z" {
+		t.Fatalf("cursor should wrap to the new front")
+	}
+	c.Close()
+	m.Compact()
+	if len(m.slab) != 2 {
+		t.Fatalf("compact after close should shrink the slab, have %d slots", len(m.slab))
+	}
+}
+
+func TestCursorAbandoned(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 4; i++ {
+		m.Set(i, i)
+	}
+	slots := len(m.slab)
+	for i := 0; i < 4; i++ {
+		c := m.Cursor()
+		c.Seek(i) // dropped without Close
+	}
+	c := m.Cursor()
+	c.Seek(1)
+	m.Delete(1)
+	m.Set(4, 4)
+	if len(m.slab) != slots {
+		t.Fatalf("slot under a cursor should be reused, slab grew to %d", len(m.slab))
+	}
+	if c.Valid() || !c.Next() || c.Key() != 0 {
+		t.Fatalf("cursor whose slot was reused should be off the list")
+	}
+	m.Delete(2)
+	m.Compact()
+	if len(m.slab) != 4 {
+		t.Fatalf("compact with live cursors kept %d slots", len(m.slab))
+	}
+	if c.Valid() || !c.Next() || c.Key() != 0 {
+		t.Fatalf("compact should move cursors off the list")
+	}
+}
+
+func TestCursorNilMap(t *testing.T) {
+	var m *OrderedMap[int, int]
+	c := m.Cursor()
+	if c.Next() || c.Front() || c.Seek(1) || c.Delete() || c.InsertAfter(1, 1) || c.Valid() {
+		t.Fatalf("cursor on nil map should be inert")
+	}
+	c.Close()
+}
+
+// TestOrderedMapIterationMutationGuarantees checks the documented rules for
+// deleting, updating and inserting while All and Backward are running.
+func TestOrderedMapIterationMutationGuarantees(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 200; round++ {
+		backward := round%2 == 1
+		m := NewOrderedMap[int, int]()
+		initial := rng.Intn(30)
+		for i := 0; i < initial; i++ {
+			m.Set(i, 0)
+		}
+		deleted := map[int]bool{}
+		produced := map[int]bool{}
+		seq := m.All()
+		if backward {
+			seq = m.Backward()
+		}
+		next := 1000
+		for k, v := range seq {
+			if deleted[k] {
+				t.Fatalf("round %d: produced deleted key %d", round, k)
+			}
+			if produced[k] {
+				t.Fatalf("round %d: produced key %d twice", round, k)
+			}
+			if v != 0 && v != k {
+				t.Fatalf("round %d: stale value %d for %d", round, v, k)
+			}
+			produced[k] = true
+			for n := rng.Intn(3); n > 0; n-- {
+				victim := rng.Intn(initial + 1)
+				if rng.Intn(4) == 0 {
+					victim = k
+				}
+				if m.Delete(victim) {
+					deleted[victim] = true
+				}
+			}
+			if rng.Intn(3) == 0 {
+				m.Set(next, next)
+				next++
+			}
+			if u := rng.Intn(initial + 1); m.Has(u) {
+				m.Set(u, u)
+			}
+		}
+		for i := 0; i < initial; i++ {
+			if !deleted[i] && !produced[i] {
+				t.Fatalf("round %d: live key %d was skipped", round, i)
+			}
+		}
+	}
+}
+
+func TestOrderedMapIterationSeesUpdatedValues(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	got := map[string]int{}
+	for k, v := range m.All() {
+		got[k] = v
+		m.Set("b", 20)
+	}
+	if got["b"] != 20 {
+		t.Fatalf("expected updated value, got %d", got["b"])
+	}
+}
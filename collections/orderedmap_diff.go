@@ -0,0 +1,202 @@
+package collections
+
+import "sort"
+
+// ChangeKind describes one entry of an OrderedMap diff.
+type ChangeKind uint8
+
+const (
+	// ChangeAdded marks a key present only in the target map.
+	ChangeAdded ChangeKind = iota + 1
+	// ChangeRemoved marks a key present only in the source map.
+	ChangeRemoved
+	// ChangeUpdated marks a key whose value differs between the maps.
+	ChangeUpdated
+	// ChangeMoved marks a key whose position relative to the other shared
+	// keys differs between the maps.
+	ChangeMoved
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeUpdated:
+		return "updated"
+	case ChangeMoved:
+		return "moved"
+	}
+	return "unknown"
+}
+
+// Change is a single difference between two OrderedMaps, as produced by Diff.
+type Change[K comparable, V any] struct {
+	Kind ChangeKind
+	Key  K
+	// Value is the new value for ChangeAdded and ChangeUpdated, and the
+	// removed value for ChangeRemoved.
+	Value V
+	// Old is the previous value for ChangeUpdated.
+	Old V
+	// After is the key that precedes Key in the target map, for ChangeAdded and
+	// ChangeMoved. It is unused when Front is set.
+	After K
+	// Front reports that Key is first in the target map.
+	Front bool
+}
+
+// Diff returns the changes that turn from into to, comparing values with ==.
+// See DiffFunc.
+func Diff[K, V comparable](from, to *OrderedMap[K, V]) []Change[K, V] {
+	return DiffFunc(from, to, func(a, b V) bool { return a == b })
+}
+
+// DiffFunc returns the changes that turn from into to, comparing values with
+// eq. Removals come first in from's order, followed by additions, updates
+// and moves in to's order; a key that was both updated and moved yields two
+// changes. Moves are minimal: the keys kept in place form a longest run of
+// shared keys whose relative order is unchanged.
+//
+// Neither map is modified and access order is not refreshed.
+// Complexity: O(n log n).
+func DiffFunc[K comparable, V any](from, to *OrderedMap[K, V], eq func(a, b V) bool) []Change[K, V] {
+	var changes []Change[K, V]
+	fromPos := make(map[K]int, from.Len())
+	for k, v := range from.All() {
+		if !to.Has(k) {
+			changes = append(changes, Change[K, V]{Kind: ChangeRemoved, Key: k, Value: v})
+			continue
+		}
+		fromPos[k] = len(fromPos)
+	}
+
+	// Positions in from of the shared keys, in to's order. Keys on a longest
+	// increasing subsequence stay put; every other shared key moved.
+	var shared []int
+	for k := range to.All() {
+		if p, ok := fromPos[k]; ok {
+			shared = append(shared, p)
+		}
+	}
+	stable := longestIncreasing(shared)
+
+	var (
+		prev  K
+		front = true
+		j     int
+	)
+	for k, v := range to.All() {
+		c := Change[K, V]{Key: k, After: prev, Front: front}
+		if _, ok := fromPos[k]; !ok {
+			c.Kind, c.Value = ChangeAdded, v
+			changes = append(changes, c)
+		} else {
+			if ov := from.slab[from.nodes[k]].value; !eq(ov, v) {
+				changes = append(changes, Change[K, V]{Kind: ChangeUpdated, Key: k, Value: v, Old: ov})
+			}
+			if !stable[j] {
+				c.Kind = ChangeMoved
+				changes = append(changes, c)
+			}
+			j++
+		}
+		prev, front = k, false
+	}
+	return changes
+}
+
+// Apply replays changes produced by Diff or DiffFunc onto m. Applied to a map
+// equal to the diff's source map, it makes m equal to the target map,
+// including its order.
+//
+// Apply is lenient when m has drifted from the source map: removing or moving
+// an absent key is ignored, updating an absent key or adding a present one
+// sets its value, and an entry whose After key is absent goes to the back.
+// Apply does not refresh access order. Complexity: O(len(changes)).
+func (m *OrderedMap[K, V]) Apply(changes []Change[K, V]) {
+	if m == nil {
+		return
+	}
+	m.ensure()
+	for _, c := range changes {
+		i, ok := m.nodes[c.Key]
+		switch c.Kind {
+		case ChangeRemoved:
+			m.Delete(c.Key)
+		case ChangeUpdated:
+			if ok {
+				m.slab[i].value = c.Value
+			} else {
+				m.place(c.Key, c.Value, m.slab[0].prev)
+			}
+		case ChangeAdded:
+			if ok {
+				m.slab[i].value = c.Value
+				m.reposition(i, m.anchor(c))
+			} else {
+				m.place(c.Key, c.Value, m.anchor(c))
+			}
+		case ChangeMoved:
+			if ok {
+				m.reposition(i, m.anchor(c))
+			}
+		}
+	}
+}
+
+// anchor returns the slot a change's key should follow.
+func (m *OrderedMap[K, V]) anchor(c Change[K, V]) int32 {
+	if c.Front {
+		return 0
+	}
+	if at, ok := m.nodes[c.After]; ok {
+		return at
+	}
+	return m.slab[0].prev
+}
+
+// place inserts a new key after slot at.
+func (m *OrderedMap[K, V]) place(k K, v V, at int32) {
+	i := m.alloc(k, v)
+	m.linkAfter(i, at)
+	m.nodes[k] = i
+	m.length++
+	m.afterInsert()
+}
+
+// reposition moves slot i to follow slot at.
+func (m *OrderedMap[K, V]) reposition(i, at int32) {
+	if i != at && m.slab[at].next != i {
+		m.unlink(i)
+		m.linkAfter(i, at)
+	}
+}
+
+// longestIncreasing reports which elements of s belong to one of its longest
+// strictly increasing subsequences.
+func longestIncreasing(s []int) []bool {
+	tails := make([]int, 0, len(s)) // tails[l] indexes the smallest tail of a run of length l+1
+	prev := make([]int, len(s))
+	for i, x := range s {
+		l := sort.Search(len(tails), func(j int) bool { return s[tails[j]] >= x })
+		if l > 0 {
+			prev[i] = tails[l-1]
+		} else {
+			prev[i] = -1
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+	in := make([]bool, len(s))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			in[i] = true
+		}
+	}
+	return in
+}
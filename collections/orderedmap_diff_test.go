@@ -0,0 +1,116 @@
+package collections
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func orderedMapOf(kv ...int) *OrderedMap[int, int] {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i+1 < len(kv); i += 2 {
+		m.Set(kv[i], kv[i+1])
+	}
+	return m
+}
+
+func TestOrderedMapDiff(t *testing.T) {
+	from := orderedMapOf(1, 10, 2, 20, 3, 30, 4, 40)
+	to := orderedMapOf(2, 20, 5, 50, 4, 41, 1, 10)
+
+	got := Diff(from, to)
+	want := []Change[int, int]{
+		{Kind: ChangeRemoved, Key: 3, Value: 30},
+		{Kind: ChangeAdded, Key: 5, Value: 50, After: 2},
+		{Kind: ChangeUpdated, Key: 4, Value: 41, Old: 40},
+		{Kind: ChangeMoved, Key: 1, After: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff\n got %+v\nwant %+v", got, want)
+	}
+
+	from.Apply(got)
+	checkOrder(t, from, 2, 5, 4, 1)
+	if v, _ := from.Get(4); v != 41 {
+		t.Fatalf("value of 4 = %d", v)
+	}
+}
+
+func TestOrderedMapDiffEqualAndEmpty(t *testing.T) {
+	a := orderedMapOf(1, 1, 2, 2)
+	if d := Diff(a, orderedMapOf(1, 1, 2, 2)); d != nil {
+		t.Fatalf("diff of equal maps %+v", d)
+	}
+	d := Diff(nil, a)
+	if len(d) != 2 || d[0].Kind != ChangeAdded || !d[0].Front || d[1].After != 1 {
+		t.Fatalf("diff from nil %+v", d)
+	}
+	if d := Diff(a, nil); len(d) != 2 || d[0].Kind != ChangeRemoved {
+		t.Fatalf("diff to nil %+v", d)
+	}
+}
+
+func TestOrderedMapDiffFunc(t *testing.T) {
+	from := NewOrderedMap[string, []string]()
+	from.Set("a", []string{"x"})
+	to := NewOrderedMap[string, []string]()
+	to.Set("a", []string{"x"})
+	eq := func(a, b []string) bool { return reflect.DeepEqual(a, b) }
+	if d := DiffFunc(from, to, eq); d != nil {
+		t.Fatalf("diff %+v", d)
+	}
+	to.Set("a", []string{"y"})
+	if d := DiffFunc(from, to, eq); len(d) != 1 || d[0].Kind != ChangeUpdated {
+		t.Fatalf("diff %+v", d)
+	}
+}
+
+func TestOrderedMapDiffMovesAreMinimal(t *testing.T) {
+	from := orderedMapOf(1, 0, 2, 0, 3, 0, 4, 0, 5, 0)
+	to := orderedMapOf(5, 0, 1, 0, 2, 0, 3, 0, 4, 0)
+	d := Diff(from, to)
+	if len(d) != 1 || d[0].Kind != ChangeMoved || d[0].Key != 5 || !d[0].Front {
+		t.Fatalf("diff %+v", d)
+	}
+}
+
+func TestOrderedMapApplyRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		from, to := NewOrderedMap[int, int](), NewOrderedMap[int, int]()
+		for _, k := range rng.Perm(20)[:rng.Intn(20)] {
+			from.Set(k, rng.Intn(3))
+		}
+		for _, k := range rng.Perm(20)[:rng.Intn(20)] {
+			to.Set(k, rng.Intn(3))
+		}
+		d := Diff(from, to)
+		from.Apply(d)
+		if !reflect.DeepEqual(from.Keys(), to.Keys()) || !reflect.DeepEqual(from.ValuesSlice(), to.ValuesSlice()) {
+			t.Fatalf("round %d: apply gave %v %v, want %v %v (diff %+v)",
+				round, from.Keys(), from.ValuesSlice(), to.Keys(), to.ValuesSlice(), d)
+		}
+	}
+}
+
+func TestOrderedMapApplyLenient(t *testing.T) {
+	m := orderedMapOf(1, 1, 2, 2)
+	m.Apply([]Change[int, int]{
+		{Kind: ChangeRemoved, Key: 9},
+		{Kind: ChangeMoved, Key: 8, After: 1},
+		{Kind: ChangeUpdated, Key: 3, Value: 3},
+		{Kind: ChangeAdded, Key: 1, Value: 10, After: 2},
+		{Kind: ChangeAdded, Key: 4, Value: 4, After: 7},
+	})
+	checkOrder(t, m, 2, 1, 3, 4)
+	if v, _ := m.Get(1); v != 10 {
+		t.Fatalf("value of 1 = %d", v)
+	}
+}
+
+func TestOrderedMapApplyKeepsAccessOrder(t *testing.T) {
+	m := orderedMapOf(1, 1, 2, 2)
+	m.SetAccessOrder(true)
+	m.Apply([]Change[int, int]{{Kind: ChangeUpdated, Key: 1, Value: 5}})
+	checkOrder(t, m, 1, 2)
+}
@@ -0,0 +1,215 @@
+package collections
+
+import "math/rand/v2"
+
+// rankNode is a node of the positional index, a treap over the list's slots
+// in iteration order. Links are slab indices, with 0 meaning none; size
+// counts the slots in the subtree.
+type rankNode struct {
+	left, right, parent int32
+	size                int32
+	prio                uint32
+}
+
+// SetIndexed turns the positional index on or off. While it is on, the map
+// keeps an order-statistic tree alongside the list, so At, IndexOf and Slice
+// are O(log n) whatever the workload, at the cost of O(log n) expected work
+// and 20 bytes per slot on every insert, delete and move. Turning it on
+// builds the tree in O(n); turning it off releases it.
+func (m *OrderedMap[K, V]) SetIndexed(enabled bool) {
+	if m == nil || enabled == m.indexed {
+		return
+	}
+	m.indexed = enabled
+	if enabled {
+		m.ensure()
+		m.reindex()
+	} else {
+		m.tree, m.root = nil, 0
+	}
+}
+
+// Indexed reports whether the positional index is on.
+func (m *OrderedMap[K, V]) Indexed() bool {
+	return m != nil && m.indexed
+}
+
+// reindex rebuilds the tree from the list in O(n), as a Cartesian tree of
+// fresh random priorities, which is a treap with the expected shape.
+func (m *OrderedMap[K, V]) reindex() {
+	if len(m.tree) < len(m.slab) {
+		m.tree = make([]rankNode, len(m.slab), cap(m.slab))
+	} else {
+		clear(m.tree)
+	}
+	t := m.tree
+	// spine holds the right spine of the tree built so far; a slot is
+	// complete once it is popped, so its size can be set then.
+	spine := make([]int32, 0, 32)
+	pop := func() int32 {
+		j := spine[len(spine)-1]
+		spine = spine[:len(spine)-1]
+		t[j].size = 1 + t[t[j].left].size + t[t[j].right].size
+		return j
+	}
+	for i := m.slab[0].next; i != 0; i = m.slab[i].next {
+		t[i] = rankNode{prio: rand.Uint32()}
+		last := int32(0)
+		for len(spine) > 0 && t[spine[len(spine)-1]].prio < t[i].prio {
+			last = pop()
+		}
+		t[i].left = last
+		if last != 0 {
+			t[last].parent = i
+		}
+		if len(spine) > 0 {
+			top := spine[len(spine)-1]
+			t[top].right = i
+			t[i].parent = top
+		}
+		spine = append(spine, i)
+	}
+	m.root = 0
+	for len(spine) > 0 {
+		m.root = pop()
+	}
+}
+
+// treeInsert adds the detached slot i to the tree after slot at, where
+// at == 0 inserts it first.
+func (m *OrderedMap[K, V]) treeInsert(i, at int32) {
+	if len(m.tree) < len(m.slab) {
+		m.tree = append(m.tree, make([]rankNode, len(m.slab)-len(m.tree))...)
+	}
+	t := m.tree
+	t[i] = rankNode{size: 1, prio: rand.Uint32()}
+	// The successor position is the right child of at if it has none, and
+	// otherwise the left child of the first slot in at's right subtree.
+	p, right := at, true
+	switch {
+	case at == 0:
+		p, right = m.root, false
+	case t[at].right != 0:
+		p, right = t[at].right, false
+	}
+	if p == 0 {
+		m.root = i
+		return
+	}
+	if !right {
+		for t[p].left != 0 {
+			p = t[p].left
+		}
+		t[p].left = i
+	} else {
+		t[p].right = i
+	}
+	t[i].parent = p
+	for j := p; j != 0; j = t[j].parent {
+		t[j].size++
+	}
+	for t[i].parent != 0 && t[t[i].parent].prio < t[i].prio {
+		m.rotateUp(i)
+	}
+}
+
+// treeRemove removes slot i from the tree.
+func (m *OrderedMap[K, V]) treeRemove(i int32) {
+	t := m.tree
+	for t[i].left != 0 && t[i].right != 0 {
+		if t[t[i].left].prio > t[t[i].right].prio {
+			m.rotateUp(t[i].left)
+		} else {
+			m.rotateUp(t[i].right)
+		}
+	}
+	c := t[i].left | t[i].right
+	p := t[i].parent
+	m.replaceChild(p, i, c)
+	if c != 0 {
+		t[c].parent = p
+	}
+	for j := p; j != 0; j = t[j].parent {
+		t[j].size--
+	}
+}
+
+// rotateUp rotates slot x above its parent, keeping the in-order sequence.
+func (m *OrderedMap[K, V]) rotateUp(x int32) {
+	t := m.tree
+	p := t[x].parent
+	g := t[p].parent
+	if t[p].left == x {
+		b := t[x].right
+		t[p].left, t[x].right = b, p
+		if b != 0 {
+			t[b].parent = p
+		}
+	} else {
+		b := t[x].left
+		t[p].right, t[x].left = b, p
+		if b != 0 {
+			t[b].parent = p
+		}
+	}
+	t[p].parent, t[x].parent = x, g
+	m.replaceChild(g, p, x)
+	t[p].size = 1 + t[t[p].left].size + t[t[p].right].size
+	t[x].size = 1 + t[t[x].left].size + t[t[x].right].size
+}
+
+// replaceChild makes c take old's place under parent p, or at the root.
+func (m *OrderedMap[K, V]) replaceChild(p, old, c int32) {
+	switch {
+	case p == 0:
+		m.root = c
+	case m.tree[p].left == old:
+		m.tree[p].left = c
+	default:
+		m.tree[p].right = c
+	}
+}
+
+// rank returns the position of slot i.
+func (m *OrderedMap[K, V]) rank(i int32) int {
+	t := m.tree
+	r := t[t[i].left].size
+	for ; t[i].parent != 0; i = t[i].parent {
+		if p := t[i].parent; t[p].right == i {
+			r += t[t[p].left].size + 1
+		}
+	}
+	return int(r)
+}
+
+// slotAt returns the slot at position pos, which must be in range.
+func (m *OrderedMap[K, V]) slotAt(pos int) int32 {
+	if !m.indexed {
+		s := m.slab
+		if pos < m.length/2 {
+			i := s[0].next
+			for ; pos > 0; pos-- {
+				i = s[i].next
+			}
+			return i
+		}
+		i := s[0].prev
+		for pos = m.length - 1 - pos; pos > 0; pos-- {
+			i = s[i].prev
+		}
+		return i
+	}
+	t := m.tree
+	i, r := m.root, int32(pos)
+	for {
+		switch l := t[t[i].left].size; {
+		case r < l:
+			i = t[i].left
+		case r == l:
+			return i
+		default:
+			r -= l + 1
+			i = t[i].right
+		}
+	}
+}
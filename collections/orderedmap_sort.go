@@ -0,0 +1,76 @@
+package collections
+
+import "slices"
+
+// Entry is a key-value pair.
+type Entry[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// SortFunc reorders the map in place so that iteration follows cmp, which
+// compares entries as for slices.SortFunc. The sort is not stable. Entries
+// keep their slots and are only relinked, so sorting allocates nothing but a
+// slice of slot numbers. Complexity: O(n log n).
+func (m *OrderedMap[K, V]) SortFunc(cmp func(a, b Entry[K, V]) int) {
+	m.sortFunc(cmp, false)
+}
+
+// SortStableFunc is like SortFunc but keeps entries that compare equal in
+// their current order.
+func (m *OrderedMap[K, V]) SortStableFunc(cmp func(a, b Entry[K, V]) int) {
+	m.sortFunc(cmp, true)
+}
+
+// Reverse reverses the iteration order in place. Complexity: O(n).
+func (m *OrderedMap[K, V]) Reverse() {
+	if m == nil || m.length < 2 {
+		return
+	}
+	order := m.order()
+	slices.Reverse(order)
+	m.relink(order)
+}
+
+func (m *OrderedMap[K, V]) sortFunc(cmp func(a, b Entry[K, V]) int, stable bool) {
+	if m == nil || m.length < 2 {
+		return
+	}
+	order := m.order()
+	s := m.slab
+	byEntry := func(i, j int32) int {
+		return cmp(Entry[K, V]{s[i].key, s[i].value}, Entry[K, V]{s[j].key, s[j].value})
+	}
+	if stable {
+		slices.SortStableFunc(order, byEntry)
+	} else {
+		slices.SortFunc(order, byEntry)
+	}
+	m.relink(order)
+}
+
+// order returns the live slots in iteration order.
+func (m *OrderedMap[K, V]) order() []int32 {
+	order := make([]int32, 0, m.length)
+	for i := m.slab[0].next; i != 0; i = m.slab[i].next {
+		order = append(order, i)
+	}
+	return order
+}
+
+// relink rebuilds the list links, and the positional index if there is one,
+// to follow order.
+func (m *OrderedMap[K, V]) relink(order []int32) {
+	prev := int32(0)
+	for _, i := range order {
+		m.slab[i].prev = prev
+		m.slab[prev].next = i
+		prev = i
+	}
+	m.slab[prev].next = 0
+	m.slab[0].prev = prev
+	m.mods++
+	if m.indexed {
+		m.reindex()
+	}
+}
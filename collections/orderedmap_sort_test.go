@@ -0,0 +1,88 @@
+package collections
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+)
+
+func TestOrderedMapSortFunc(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for i, k := range []string{"d", "b", "a", "c"} {
+		m.Set(k, i)
+	}
+	slab := &m.slab[0]
+	m.SortFunc(func(a, b Entry[string, int]) int { return strings.Compare(a.Key, b.Key) })
+	checkOrder(t, m, "a", "b", "c", "d")
+	if &m.slab[0] != slab {
+		t.Fatalf("sort reallocated the slab")
+	}
+
+	m.SortFunc(func(a, b Entry[string, int]) int { return cmp.Compare(a.Value, b.Value) })
+	checkOrder(t, m, "d", "b", "a", "c")
+
+	// The positional index and appends keep working after a sort.
+	if k, _ := m.At(2); k != "a" || m.IndexOf("c") != 3 {
+		t.Fatalf("positions after sort: At(2)=%q IndexOf(c)=%d", k, m.IndexOf("c"))
+	}
+	m.Set("e", 9)
+	m.Delete("d")
+	checkOrder(t, m, "b", "a", "c", "e")
+	if k, _ := m.At(3); k != "e" || m.IndexOf("b") != 0 {
+		t.Fatalf("positions after append: At(3)=%q IndexOf(b)=%d", k, m.IndexOf("b"))
+	}
+}
+
+func TestOrderedMapSortStableFunc(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for _, k := range []string{"x1", "y1", "x2", "y2", "x3"} {
+		m.Set(k, int(k[0]))
+	}
+	m.SortStableFunc(func(a, b Entry[string, int]) int { return cmp.Compare(b.Value, a.Value) })
+	checkOrder(t, m, "y1", "y2", "x1", "x2", "x3")
+}
+
+func TestOrderedMapReverse(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	m.Reverse()
+	m.Set(1, 1)
+	m.Reverse()
+	checkOrder(t, m, 1)
+	for k := 2; k <= 5; k++ {
+		m.Set(k, k)
+	}
+	m.Reverse()
+	checkOrder(t, m, 5, 4, 3, 2, 1)
+	var back []int
+	for k := range m.Backward() {
+		back = append(back, k)
+	}
+	if len(back) != 5 || back[0] != 1 || back[4] != 5 {
+		t.Fatalf("backward after reverse %v", back)
+	}
+	m.MoveToFront(1)
+	m.Reverse()
+	checkOrder(t, m, 2, 3, 4, 5, 1)
+
+	var nilMap *OrderedMap[int, int]
+	nilMap.Reverse()
+	nilMap.SortFunc(nil)
+}
+
+func TestOrderedMapSortDuringIteration(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for k := range 10 {
+		m.Set(k, k)
+	}
+	n := 0
+	for k := range m.All() {
+		if k == 3 {
+			m.Delete(3)
+			m.Reverse()
+		}
+		if n++; n > 20 {
+			t.Fatalf("iteration did not terminate")
+		}
+	}
+	checkOrder(t, m, 9, 8, 7, 6, 5, 4, 2, 1, 0)
+}
@@ -1,6 +1,17 @@
 package collections
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
 
 func TestOrderedMapSetGetDelete(t *testing.T) {
 	m := NewOrderedMap[int, string]()
@@ -86,3 +97,712 @@ func TestOrderedMapDeletionLinks(t *testing.T) {
 		}
 	}
 }
+
+type jsonPoint struct{ X, Y int }
+
+func (p *jsonPoint) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d:%d", &p.X, &p.Y)
+	return err
+}
+
+func TestOrderedMapUnmarshalJSONPreservesOrder(t *testing.T) {
+	var m OrderedMap[string, int]
+	if err := json.Unmarshal([]byte(`{"z":1,"a":2,"m":3,"a":4}`), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got, want := m.Keys(), []string{"z", "a", "m"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys %v, want %v", got, want)
+	}
+	if v, _ := m.Get("a"); v != 4 {
+		t.Fatalf("duplicate key should keep last value, got %d", v)
+	}
+
+	m.Set("extra", 9)
+	if err := json.Unmarshal([]byte(`{"b":1}`), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("existing entries should be replaced, got %v", got)
+	}
+	if err := json.Unmarshal([]byte(`null`), &m); err != nil || m.Len() != 1 {
+		t.Fatalf("null should be a no-op")
+	}
+}
+
+func TestOrderedMapUnmarshalJSONAccessOrder(t *testing.T) {
+	var m OrderedMap[string, int]
+	m.SetAccessOrder(true)
+	if err := json.Unmarshal([]byte(`{"a":1,"b":2,"a":3}`), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got, want := m.Values(), []int{3, 2}; !reflect.DeepEqual(m.Keys(), []string{"a", "b"}) || !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys %v values %v, want [a b] %v", m.Keys(), got, want)
+	}
+
+	// The bound is enforced once decoding is done, keeping the newest keys.
+	var bounded OrderedMap[string, int]
+	bounded.SetAccessOrder(true)
+	bounded.SetRemoveEldestFunc(func(string, int) bool { return bounded.Len() > 2 })
+	if err := json.Unmarshal([]byte(`{"a":1,"b":2,"a":3,"c":4}`), &bounded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := bounded.Keys(); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Fatalf("bounded keys %v, want [b c]", got)
+	}
+}
+
+func TestOrderedMapUnmarshalJSONKeys(t *testing.T) {
+	ints := NewOrderedMap[int8, bool]()
+	if err := json.Unmarshal([]byte(`{"3":true,"-1":false,"2":true}`), ints); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := ints.Keys(); !reflect.DeepEqual(got, []int8{3, -1, 2}) {
+		t.Fatalf("int keys %v", got)
+	}
+	if err := json.Unmarshal([]byte(`{"300":true}`), ints); err == nil {
+		t.Fatalf("expected overflow error")
+	}
+	uints := NewOrderedMap[uint, bool]()
+	if err := json.Unmarshal([]byte(`{"-1":true}`), uints); err == nil {
+		t.Fatalf("expected error for negative uint key")
+	}
+
+	points := NewOrderedMap[jsonPoint, string]()
+	if err := json.Unmarshal([]byte(`{"2:3":"b","0:1":"a"}`), points); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := points.Keys(); !reflect.DeepEqual(got, []jsonPoint{{2, 3}, {0, 1}}) {
+		t.Fatalf("text keys %v", got)
+	}
+
+	floats := NewOrderedMap[float64, int]()
+	if err := json.Unmarshal([]byte(`{"1.5":1}`), floats); err == nil {
+		t.Fatalf("expected error for float keys")
+	}
+}
+
+func TestOrderedMapUnmarshalJSONNested(t *testing.T) {
+	type server struct {
+		Host  string   `json:"host"`
+		Ports []int    `json:"ports"`
+		Tags  []string `json:"tags,omitempty"`
+	}
+	var m OrderedMap[string, *OrderedMap[string, server]]
+	data := `{"prod":{"web":{"host":"w","ports":[80,443]},"api":{"host":"a","ports":[8080]}},"dev":{}}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"prod", "dev"}) {
+		t.Fatalf("outer keys %v", got)
+	}
+	prod, _ := m.Get("prod")
+	if got := prod.Keys(); !reflect.DeepEqual(got, []string{"web", "api"}) {
+		t.Fatalf("inner keys %v", got)
+	}
+	if web, _ := prod.Get("web"); web.Host != "w" || !reflect.DeepEqual(web.Ports, []int{80, 443}) {
+		t.Fatalf("inner value %+v", web)
+	}
+
+	out, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != data {
+		t.Fatalf("round trip mismatch:\n got %s\nwant %s", out, data)
+	}
+}
+
+func TestOrderedMapUnmarshalJSONErrors(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for _, data := range []string{`[1,2]`, `"x"`, `{"a":"b"}`, `{"a":1`, `{"a":1} {}`} {
+		if err := m.UnmarshalJSON([]byte(data)); err == nil {
+			t.Fatalf("expected error for %s", data)
+		}
+	}
+	var bad OrderedMap[[2]int, int]
+	if err := json.Unmarshal([]byte(`{}`), &bad); err == nil {
+		t.Fatalf("expected error for unsupported key type")
+	}
+}
+
+func (p jsonPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d:%d", p.X, p.Y)), nil
+}
+
+type refKey struct{ id int }
+
+func (k *refKey) MarshalText() ([]byte, error) { return []byte("ref" + strconv.Itoa(k.id)), nil }
+
+type failKey int
+
+func (failKey) MarshalText() ([]byte, error) { return nil, errors.New("no text") }
+
+type spacedValue struct{ N int }
+
+func (v spacedValue) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("{ \"n\" : %d,\n \"html\": \"<&>\" }", v.N)), nil
+}
+
+type jsonEntry[K comparable, V any] struct {
+	k K
+	v V
+}
+
+// marshalLikeMap checks that an OrderedMap holding entries encodes exactly as
+// the equivalent map[K]V does. Entries must be listed in the order
+// encoding/json sorts map keys.
+func marshalLikeMap[K comparable, V any](t *testing.T, entries ...jsonEntry[K, V]) {
+	t.Helper()
+	std := make(map[K]V, len(entries))
+	om := NewOrderedMap[K, V]()
+	for _, e := range entries {
+		std[e.k] = e.v
+		om.Set(e.k, e.v)
+	}
+	want, err := json.Marshal(std)
+	if err != nil {
+		t.Fatalf("std marshal: %v", err)
+	}
+	got, err := json.Marshal(om)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("json.Marshal mismatch:\n got %s\nwant %s", got, want)
+	}
+	direct, err := om.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	if !bytes.Equal(direct, want) {
+		t.Fatalf("MarshalJSON mismatch:\n got %s\nwant %s", direct, want)
+	}
+}
+
+func TestOrderedMapMarshalJSONMatchesStdlib(t *testing.T) {
+	marshalLikeMap[string, string](t)
+	marshalLikeMap(t,
+		jsonEntry[string, string]{"&", "<b>"},
+		jsonEntry[string, string]{"<a>", "x y"},
+		jsonEntry[string, string]{"café", "\xff"},
+	)
+	marshalLikeMap(t,
+		jsonEntry[int, bool]{-1, true},
+		jsonEntry[int, bool]{10, false},
+		jsonEntry[int, bool]{2, true},
+	)
+	marshalLikeMap(t,
+		jsonEntry[uint8, float64]{0, 0.5},
+		jsonEntry[uint8, float64]{255, 1e21},
+	)
+	marshalLikeMap(t,
+		jsonEntry[jsonPoint, []int]{jsonPoint{0, 1}, nil},
+		jsonEntry[jsonPoint, []int]{jsonPoint{2, 3}, []int{1, 2}},
+	)
+	marshalLikeMap(t,
+		jsonEntry[*refKey, spacedValue]{nil, spacedValue{0}},
+		jsonEntry[*refKey, spacedValue]{&refKey{7}, spacedValue{7}},
+	)
+}
+
+func TestOrderedMapMarshalJSONOrderAndErrors(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	m.Set(10, "a")
+	m.Set(-2, "b")
+	m.Set(3, "c")
+	out, err := json.Marshal(m)
+	if err != nil || string(out) != `{"10":"a","-2":"b","3":"c"}` {
+		t.Fatalf("got %s, %v", out, err)
+	}
+
+	var nilMap *OrderedMap[string, int]
+	if out, err := json.Marshal(nilMap); err != nil || string(out) != "null" {
+		t.Fatalf("nil map: got %s, %v", out, err)
+	}
+
+	floats := NewOrderedMap[float64, int]()
+	floats.Set(1.5, 1)
+	if _, err := json.Marshal(floats); err == nil {
+		t.Fatalf("expected error for float keys")
+	}
+	if _, err := NewOrderedMap[bool, int]().MarshalJSON(); err == nil {
+		t.Fatalf("expected error for bool keys even when empty")
+	}
+	if _, err := NewOrderedMap[any, int]().MarshalJSON(); err == nil {
+		t.Fatalf("expected error for interface keys")
+	}
+	failing := NewOrderedMap[failKey, int]()
+	failing.Set(1, 1)
+	if _, err := failing.MarshalJSON(); err == nil {
+		t.Fatalf("expected MarshalText error")
+	}
+}
+
+// checkOrder verifies forward and backward traversal agree with want.
+func checkOrder[K comparable, V any](t *testing.T, m *OrderedMap[K, V], want ...K) {
+	t.Helper()
+	if got := m.Keys(); !reflect.DeepEqual(got, want) && (len(got) != 0 || len(want) != 0) {
+		t.Fatalf("keys %v, want %v", got, want)
+	}
+	var back []K
+	for k := range m.Backward() {
+		back = append(back, k)
+	}
+	for i := range back {
+		if back[i] != want[len(want)-1-i] {
+			t.Fatalf("backward %v inconsistent with %v", back, want)
+		}
+	}
+	if m.Len() != len(want) || len(back) != len(want) {
+		t.Fatalf("len %d, want %d", m.Len(), len(want))
+	}
+}
+
+func TestOrderedMapMove(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for i, k := range []string{"a", "b", "c", "d"} {
+		m.Set(k, i)
+	}
+	if !m.MoveToFront("c") {
+		t.Fatalf("move to front failed")
+	}
+	checkOrder(t, m, "c", "a", "b", "d")
+	if !m.MoveToBack("a") {
+		t.Fatalf("move to back failed")
+	}
+	checkOrder(t, m, "c", "b", "d", "a")
+	if !m.MoveBefore("a", "c") {
+		t.Fatalf("move before failed")
+	}
+	checkOrder(t, m, "a", "c", "b", "d")
+	if !m.MoveAfter("c", "d") {
+		t.Fatalf("move after failed")
+	}
+	checkOrder(t, m, "a", "b", "d", "c")
+	if !m.MoveBefore("b", "d") || !m.MoveAfter("d", "b") || !m.MoveToFront("a") || !m.MoveToBack("c") {
+		t.Fatalf("no-op moves should still report true")
+	}
+	checkOrder(t, m, "a", "b", "d", "c")
+
+	if m.MoveToFront("x") || m.MoveToBack("x") || m.MoveBefore("x", "a") || m.MoveAfter("a", "x") || m.MoveBefore("a", "a") {
+		t.Fatalf("moves with missing keys should fail")
+	}
+	checkOrder(t, m, "a", "b", "d", "c")
+	if v, _ := m.Get("d"); v != 3 {
+		t.Fatalf("move should keep value, got %d", v)
+	}
+
+	var nilMap *OrderedMap[string, int]
+	if nilMap.MoveToFront("a") || nilMap.MoveAfter("a", "b") || nilMap.InsertBefore("a", 1, "b") {
+		t.Fatalf("nil map moves should fail")
+	}
+}
+
+func TestOrderedMapInsert(t *testing.T) {
+	var m OrderedMap[string, int]
+	if m.InsertAfter("a", 1, "missing") {
+		t.Fatalf("insert with missing mark should fail")
+	}
+	m.Set("mw1", 1)
+	m.Set("mw3", 3)
+	if !m.InsertAfter("mw2", 2, "mw1") || !m.InsertBefore("mw0", 0, "mw1") || !m.InsertAfter("mw4", 4, "mw3") {
+		t.Fatalf("insert failed")
+	}
+	checkOrder(t, &m, "mw0", "mw1", "mw2", "mw3", "mw4")
+	if m.InsertBefore("mw2", 99, "mw0") {
+		t.Fatalf("inserting an existing key should fail")
+	}
+	if v, _ := m.Get("mw2"); v != 2 {
+		t.Fatalf("failed insert must not update value")
+	}
+	m.Delete("mw0")
+	m.Delete("mw4")
+	if !m.InsertBefore("first", -1, "mw1") {
+		t.Fatalf("insert at head failed")
+	}
+	checkOrder(t, &m, "first", "mw1", "mw2", "mw3")
+}
+
+func TestOrderedMapAccessOrder(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	m.SetAccessOrder(true)
+	if !m.AccessOrder() {
+		t.Fatalf("access order should be enabled")
+	}
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	m.Get("a")
+	checkOrder(t, m, "b", "c", "a")
+	m.Set("b", 20)
+	checkOrder(t, m, "c", "a", "b")
+	m.Has("c")
+	m.Get("missing")
+	for range m.All() {
+	}
+	checkOrder(t, m, "c", "a", "b")
+
+	m.SetAccessOrder(false)
+	m.Get("c")
+	m.Set("c", 30)
+	checkOrder(t, m, "c", "a", "b")
+}
+
+func TestOrderedMapRemoveEldest(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	m.SetAccessOrder(true)
+	var removed []int
+	m.SetRemoveEldestFunc(func(k int, _ string) bool {
+		if m.Len() > 3 {
+			removed = append(removed, k)
+			return true
+		}
+		return false
+	})
+	for i := 1; i <= 3; i++ {
+		m.Set(i, "")
+	}
+	m.Get(1)
+	m.Set(4, "")
+	checkOrder(t, m, 3, 1, 4)
+	if !reflect.DeepEqual(removed, []int{2}) {
+		t.Fatalf("removed %v", removed)
+	}
+
+	m.Set(3, "updated")
+	if len(removed) != 1 {
+		t.Fatalf("updating an existing key must not consult the hook")
+	}
+	checkOrder(t, m, 1, 4, 3)
+	m.InsertAfter(5, "", 1)
+	checkOrder(t, m, 5, 4, 3)
+	if !reflect.DeepEqual(removed, []int{2, 1}) {
+		t.Fatalf("removed %v", removed)
+	}
+
+	m.SetRemoveEldestFunc(nil)
+	m.Set(6, "")
+	if m.Len() != 4 {
+		t.Fatalf("hook should be removed")
+	}
+}
+
+func TestOrderedMapPositional(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		m.Set(k, i)
+	}
+	if k, v := m.At(2); k != "c" || v != 2 {
+		t.Fatalf("at(2) = %s, %d", k, v)
+	}
+	if m.IndexOf("e") != 4 || m.IndexOf("missing") != -1 {
+		t.Fatalf("index of")
+	}
+
+	m.Set("f", 5)
+	m.Delete("a")
+	if k, _ := m.At(0); k != "b" || m.IndexOf("f") != 4 {
+		t.Fatalf("index should follow appends and head deletes")
+	}
+	m.MoveToFront("d")
+	if k, _ := m.At(1); k != "b" || m.IndexOf("d") != 0 || m.IndexOf("c") != 2 {
+		t.Fatalf("positions should follow a move")
+	}
+	m.SetIndexed(true)
+	if !m.Indexed() || m.IndexOf("c") != 2 || m.IndexOf("f") != 4 {
+		t.Fatalf("indexed positions")
+	}
+
+	var page []string
+	for k := range m.Slice(1, 3) {
+		page = append(page, k)
+	}
+	if !reflect.DeepEqual(page, []string{"b", "c"}) {
+		t.Fatalf("slice %v", page)
+	}
+	page = page[:0]
+	for k := range m.Slice(-3, 100) {
+		page = append(page, k)
+	}
+	if !reflect.DeepEqual(page, m.Keys()) {
+		t.Fatalf("clamped slice %v", page)
+	}
+	for range m.Slice(4, 2) {
+		t.Fatalf("empty range should yield nothing")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("out of range At should panic")
+		}
+	}()
+	m.At(m.Len())
+}
+
+func TestOrderedMapIndexedZeroValue(t *testing.T) {
+	var m OrderedMap[string, int]
+	m.SetIndexed(true)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if k, _ := m.At(1); k != "b" || m.IndexOf("a") != 0 || !m.Indexed() {
+		t.Fatalf("indexed zero-value map")
+	}
+}
+
+func TestOrderedMapPositionalRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewOrderedMap[int, int]()
+	for step := 0; step < 5000; step++ {
+		k := rng.Intn(64)
+		if step%500 == 0 {
+			m.SetIndexed(!m.Indexed())
+		}
+		switch rng.Intn(10) {
+		case 0, 1:
+			m.Set(k, step)
+		case 2:
+			m.Delete(k)
+		case 3:
+			if keys := m.Keys(); len(keys) > 0 {
+				m.Delete(keys[0])
+			}
+		case 4:
+			m.MoveToBack(k)
+		case 5:
+			m.MoveAfter(k, rng.Intn(64))
+		case 6:
+			m.InsertBefore(k, step, rng.Intn(64))
+		case 7:
+			if rng.Intn(20) == 0 {
+				m.Reverse()
+			}
+		case 8:
+			if rng.Intn(20) == 0 {
+				m.Compact()
+			}
+		case 9:
+			if rng.Intn(50) == 0 {
+				m.Clear()
+			}
+		}
+		if step%3 != 0 {
+			continue
+		}
+		keys := m.Keys()
+		var page []int
+		for k := range m.Slice(len(keys)/3, len(keys)) {
+			page = append(page, k)
+		}
+		if !slices.Equal(page, keys[len(keys)/3:]) {
+			t.Fatalf("step %d: Slice = %v, want %v", step, page, keys[len(keys)/3:])
+		}
+		for i, want := range keys {
+			if got, _ := m.At(i); got != want {
+				t.Fatalf("step %d: At(%d) = %d, want %d", step, i, got, want)
+			}
+			if m.IndexOf(want) != i {
+				t.Fatalf("step %d: IndexOf(%d) = %d, want %d", step, want, m.IndexOf(want), i)
+			}
+		}
+	}
+}
+
+func BenchmarkOrderedMapAt(b *testing.B) {
+	m := NewOrderedMap[int, int]()
+	m.SetIndexed(true)
+	for i := 0; i < 100_000; i++ {
+		m.Set(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.At(i % 100_000)
+	}
+}
+
+func TestOrderedMapSlotReuse(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	slots := len(m.slab)
+	for i := 0; i < 1000; i++ {
+		m.Delete(i)
+		m.Set(i+100, i)
+	}
+	if len(m.slab) != slots {
+		t.Fatalf("deleted slots should be reused, slab grew from %d to %d", slots, len(m.slab))
+	}
+	checkOrder(t, m, m.Keys()...)
+	if k, _ := m.At(0); k != 1000 {
+		t.Fatalf("front %d", k)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Delete(m.slab[m.slab[0].next].key)
+		m.Set(-1, 0)
+		m.Delete(-1)
+		m.Set(2000, 0)
+	})
+	if allocs != 0 {
+		t.Fatalf("steady-state churn allocated %.1f times per run", allocs)
+	}
+}
+
+func TestOrderedMapWithCapacityDoesNotAllocate(t *testing.T) {
+	m := NewOrderedMapWithCapacity[int, int](1000)
+	i := 0
+	allocs := testing.AllocsPerRun(500, func() {
+		m.Set(i, i)
+		i++
+	})
+	if allocs != 0 {
+		t.Fatalf("Set allocated %.1f times per call", allocs)
+	}
+}
+
+func TestOrderedMapCompact(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	for i := 0; i < 10; i++ {
+		m.Set(i, strconv.Itoa(i))
+	}
+	for i := 0; i < 10; i += 3 {
+		m.Delete(i)
+	}
+	m.MoveToFront(8)
+	want := m.Keys()
+	m.Compact()
+	if len(m.slab) != m.Len()+1 || m.free != 0 {
+		t.Fatalf("compact left %d slots for %d entries", len(m.slab), m.Len())
+	}
+	checkOrder(t, m, want...)
+	for _, k := range want {
+		if v, ok := m.Get(k); !ok || v != strconv.Itoa(k) {
+			t.Fatalf("get %d after compact = %q", k, v)
+		}
+	}
+	m.Set(42, "x")
+	checkOrder(t, m, append(want, 42)...)
+
+	var empty OrderedMap[int, int]
+	empty.Compact()
+	empty.Set(1, 1)
+	empty.Delete(1)
+	empty.Compact()
+	checkOrder(t, &empty)
+	empty.Set(2, 2)
+	checkOrder(t, &empty, 2)
+}
+
+func TestOrderedMapDeleteDuringIteration(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 6; i++ {
+		m.Set(i, i)
+	}
+	var seen []int
+	for k := range m.All() {
+		seen = append(seen, k)
+		m.Delete(k)
+		m.Delete(k + 1)
+		if k < 100 {
+			m.Set(k+100, 0) // must not be reached through a deleted slot
+		}
+	}
+	if !reflect.DeepEqual(seen, []int{0, 2, 4, 100, 102, 104}) {
+		t.Fatalf("seen %v", seen)
+	}
+
+	seen = seen[:0]
+	m.Clear()
+	for i := 0; i < 3; i++ {
+		m.Set(i, i)
+	}
+	for k := range m.Backward() {
+		seen = append(seen, k)
+		m.Clear()
+	}
+	if !reflect.DeepEqual(seen, []int{2}) || m.Len() != 0 {
+		t.Fatalf("clear during iteration: seen %v", seen)
+	}
+	m.Set(7, 7)
+	checkOrder(t, m, 7)
+}
+
+func TestOrderedMapNestedIterationDelete(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 4; i++ {
+		m.Set(i, i)
+	}
+	var outer []int
+	for k := range m.All() {
+		outer = append(outer, k)
+		for k2 := range m.Backward() {
+			if k2 == k {
+				m.Delete(k)
+				m.Delete(k + 1)
+				m.Set(k+10, 0)
+				m.Delete(k + 10)
+			}
+		}
+	}
+	if !reflect.DeepEqual(outer, []int{0, 2}) || m.Len() != 0 {
+		t.Fatalf("outer saw %v, %d left", outer, m.Len())
+	}
+}
+
+func TestOrderedMapPanicDuringIteration(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 3; i++ {
+		m.Set(i, i)
+	}
+	func() {
+		defer func() { _ = recover() }()
+		for range m.All() {
+			panic("boom")
+		}
+	}()
+	m.Delete(0)
+	m.Set(3, 3)
+	checkOrder(t, m, 1, 2, 3)
+	m.Delete(3)
+	m.Compact()
+	if len(m.slab) != 3 {
+		t.Fatalf("Compact after panic kept %d slots", len(m.slab))
+	}
+}
+
+func TestOrderedMapConcurrentReaders(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	m.Delete(50)
+	m.SetIndexed(true)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < 50; r++ {
+				n := 0
+				for range m.All() {
+					n++
+				}
+				for range m.Backward() {
+					n++
+				}
+				if _, err := m.MarshalJSON(); err != nil {
+					t.Error(err)
+				}
+				if k, _ := m.At(r); m.IndexOf(k) != r {
+					t.Errorf("IndexOf(At(%d)) = %d", r, m.IndexOf(k))
+				}
+				if n != 2*m.Len() || len(m.Keys()) != m.Len() {
+					t.Errorf("reader saw %d entries", n)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,249 @@
+package collections
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+)
+
+// OrderedSet is a set that remembers the order in which elements were added.
+//
+// It is backed by an OrderedMap, so Add, Remove and Has are O(1) on average
+// and iteration is O(n) in insertion order. Re-adding an element keeps its
+// original position. Set algebra results list the receiver's elements first,
+// in the receiver's order, followed by any elements taken from the argument
+// in its order. The zero value is an empty set ready to use.
+type OrderedSet[T comparable] struct {
+	m OrderedMap[T, struct{}]
+}
+
+// NewOrderedSet creates a new empty OrderedSet.
+func NewOrderedSet[T comparable]() *OrderedSet[T] {
+	return &OrderedSet[T]{}
+}
+
+// NewOrderedSetWithCapacity creates an ordered set with space preallocated
+// for the given capacity.
+func NewOrderedSetWithCapacity[T comparable](capacity int) *OrderedSet[T] {
+	return &OrderedSet[T]{m: *NewOrderedMapWithCapacity[T, struct{}](capacity)}
+}
+
+// NewOrderedSetFromSlice creates an ordered set containing the elements of
+// the slice in their first-occurrence order.
+func NewOrderedSetFromSlice[T comparable](s []T) *OrderedSet[T] {
+	set := NewOrderedSetWithCapacity[T](len(s))
+	for _, v := range s {
+		set.Add(v)
+	}
+	return set
+}
+
+// Add appends v to the set if it is not already present.
+func (s *OrderedSet[T]) Add(v T) {
+	if s == nil || s.m.Has(v) {
+		return
+	}
+	s.m.Set(v, struct{}{})
+}
+
+func (s *OrderedSet[T]) Remove(v T) {
+	if s == nil {
+		return
+	}
+	s.m.Delete(v)
+}
+
+func (s *OrderedSet[T]) Has(v T) bool {
+	if s == nil {
+		return false
+	}
+	return s.m.Has(v)
+}
+
+func (s *OrderedSet[T]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.m.Len()
+}
+
+func (s *OrderedSet[T]) Clear() {
+	if s == nil {
+		return
+	}
+	s.m.Clear()
+}
+
+// All returns an iterator over the elements in insertion order. The set may
+// be modified during iteration as described on OrderedMap.All.
+func (s *OrderedSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s == nil {
+			return
+		}
+		for v := range s.m.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the elements in reverse insertion order.
+func (s *OrderedSet[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s == nil {
+			return
+		}
+		for v := range s.m.Backward() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns the elements in insertion order.
+func (s *OrderedSet[T]) Values() []T {
+	if s == nil {
+		return nil
+	}
+	return s.m.Keys()
+}
+
+// ToSlice returns the elements in insertion order.
+func (s *OrderedSet[T]) ToSlice() []T {
+	return s.Values()
+}
+
+// Clone returns a shallow copy of the set with the same order.
+func (s *OrderedSet[T]) Clone() *OrderedSet[T] {
+	out := NewOrderedSetWithCapacity[T](s.Len())
+	if s != nil {
+		for v := range s.m.All() {
+			out.m.Set(v, struct{}{})
+		}
+	}
+	return out
+}
+
+func (s *OrderedSet[T]) Union(other *OrderedSet[T]) *OrderedSet[T] {
+	out := NewOrderedSetWithCapacity[T](s.Len() + other.Len())
+	if s != nil {
+		for v := range s.m.All() {
+			out.m.Set(v, struct{}{})
+		}
+	}
+	if other != nil {
+		for v := range other.m.All() {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+func (s *OrderedSet[T]) Intersection(other *OrderedSet[T]) *OrderedSet[T] {
+	out := NewOrderedSet[T]()
+	if s == nil || other == nil {
+		return out
+	}
+	for v := range s.m.All() {
+		if other.m.Has(v) {
+			out.m.Set(v, struct{}{})
+		}
+	}
+	return out
+}
+
+func (s *OrderedSet[T]) Difference(other *OrderedSet[T]) *OrderedSet[T] {
+	out := NewOrderedSet[T]()
+	if s == nil {
+		return out
+	}
+	for v := range s.m.All() {
+		if !other.Has(v) {
+			out.m.Set(v, struct{}{})
+		}
+	}
+	return out
+}
+
+func (s *OrderedSet[T]) SymmetricDifference(other *OrderedSet[T]) *OrderedSet[T] {
+	out := s.Difference(other)
+	if other != nil {
+		for v := range other.m.All() {
+			if !s.Has(v) {
+				out.m.Set(v, struct{}{})
+			}
+		}
+	}
+	return out
+}
+
+func (s *OrderedSet[T]) IsSubset(other *OrderedSet[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	if s == nil {
+		return true
+	}
+	for v := range s.m.All() {
+		if !other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *OrderedSet[T]) IsSuperset(other *OrderedSet[T]) bool {
+	return other.IsSubset(s)
+}
+
+func (s *OrderedSet[T]) IsDisjoint(other *OrderedSet[T]) bool {
+	if s == nil || other == nil {
+		return true
+	}
+	if s.Len() > other.Len() {
+		s, other = other, s
+	}
+	for v := range s.m.All() {
+		if other.m.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON encodes the set as a JSON array in insertion order.
+// A nil set encodes as null.
+func (s *OrderedSet[T]) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	values := s.Values()
+	if values == nil {
+		values = []T{}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements of a JSON
+// array, keeping their order and dropping duplicates. A JSON null leaves the
+// set unchanged.
+func (s *OrderedSet[T]) UnmarshalJSON(data []byte) error {
+	if s == nil {
+		return errors.New("collections: UnmarshalJSON on nil *OrderedSet")
+	}
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		return nil
+	}
+	s.Clear()
+	for _, v := range values {
+		s.Add(v)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package collections
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOrderedSetBasic(t *testing.T) {
+	var s OrderedSet[string]
+	for _, v := range []string{"c", "a", "b", "a"} {
+		s.Add(v)
+	}
+	if got := s.Values(); !reflect.DeepEqual(got, []string{"c", "a", "b"}) {
+		t.Fatalf("values %v", got)
+	}
+	s.Remove("a")
+	s.Add("a")
+	if got := s.Values(); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Fatalf("values after re-add %v", got)
+	}
+	var back []string
+	for v := range s.Backward() {
+		back = append(back, v)
+	}
+	if !reflect.DeepEqual(back, []string{"a", "b", "c"}) {
+		t.Fatalf("backward %v", back)
+	}
+	if !s.Has("b") || s.Len() != 3 {
+		t.Fatalf("has/len")
+	}
+	s.Clear()
+	if s.Len() != 0 || s.Values() != nil {
+		t.Fatalf("clear")
+	}
+}
+
+func TestOrderedSetAlgebraKeepsOrder(t *testing.T) {
+	a := NewOrderedSetFromSlice([]int{5, 1, 4, 2})
+	b := NewOrderedSetFromSlice([]int{3, 2, 6, 5})
+
+	cases := []struct {
+		name string
+		got  *OrderedSet[int]
+		want []int
+	}{
+		{"union", a.Union(b), []int{5, 1, 4, 2, 3, 6}},
+		{"intersection", a.Intersection(b), []int{5, 2}},
+		{"intersection reversed", b.Intersection(a), []int{2, 5}},
+		{"difference", a.Difference(b), []int{1, 4}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 4, 3, 6}},
+		{"clone", a.Clone(), []int{5, 1, 4, 2}},
+	}
+	for _, c := range cases {
+		if got := c.got.Values(); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	sub := NewOrderedSetFromSlice([]int{2, 5})
+	if !sub.IsSubset(a) || !a.IsSuperset(sub) || a.IsSubset(sub) {
+		t.Fatalf("subset")
+	}
+	if a.IsDisjoint(b) || !sub.IsDisjoint(NewOrderedSetFromSlice([]int{7})) {
+		t.Fatalf("disjoint")
+	}
+}
+
+func TestOrderedSetNil(t *testing.T) {
+	var s *OrderedSet[int]
+	s.Add(1)
+	s.Remove(1)
+	if s.Has(1) || s.Len() != 0 || s.Values() != nil {
+		t.Fatalf("nil set should behave as empty")
+	}
+	if got := s.Union(NewOrderedSetFromSlice([]int{1})).Values(); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("nil union %v", got)
+	}
+	if !s.IsSubset(nil) || !s.IsDisjoint(nil) || s.Difference(nil).Len() != 0 {
+		t.Fatalf("nil algebra")
+	}
+}
+
+func TestOrderedSetJSON(t *testing.T) {
+	s := NewOrderedSetFromSlice([]string{"z", "a", "m"})
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["z","a","m"]` {
+		t.Fatalf("marshal %s", data)
+	}
+	if data, _ := json.Marshal(NewOrderedSet[int]()); string(data) != `[]` {
+		t.Fatalf("marshal empty %s", data)
+	}
+
+	var got OrderedSet[string]
+	if err := json.Unmarshal([]byte(`["b","a","b","c"]`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Values(), []string{"b", "a", "c"}) {
+		t.Fatalf("unmarshal %v", got.Values())
+	}
+	if err := json.Unmarshal([]byte(`null`), &got); err != nil || got.Len() != 3 {
+		t.Fatalf("null should leave the set unchanged: %v %d", err, got.Len())
+	}
+	if err := json.Unmarshal([]byte(`{"a":1}`), &got); err == nil {
+		t.Fatalf("expected error for object")
+	}
+
+	var wrapped struct {
+		Tags *OrderedSet[string] `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(`{"tags":["x","y"]}`), &wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wrapped.Tags.Values(), []string{"x", "y"}) {
+		t.Fatalf("nested %v", wrapped.Tags.Values())
+	}
+}
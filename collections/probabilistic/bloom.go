@@ -0,0 +1,241 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// BloomFilter is a probabilistic set that reports whether an item may have
+// been added.
+//
+// Has never returns false for an added item, and returns true for an item
+// that was not added with roughly the false-positive rate the filter was
+// sized for, as long as no more than the expected number of items are
+// added. Items cannot be removed. Each item sets k bits chosen by double
+// hashing a single 64-bit hash, so Add and Has are O(k).
+//
+// A BloomFilter must be created with NewBloomFilter or
+// NewBloomFilterWithSize; methods on a nil *BloomFilter behave like an empty
+// filter.
+type BloomFilter[T any] struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+	hash collections.Hasher[T]
+}
+
+// NewBloomFilter creates a filter sized to hold expectedItems with the given
+// false-positive rate, which must be between 0 and 1 exclusive. An
+// expectedItems below 1 is treated as 1.
+func NewBloomFilter[T any](expectedItems int, falsePositiveRate float64, hash collections.Hasher[T]) *BloomFilter[T] {
+	m, k := bloomShape(expectedItems, falsePositiveRate)
+	return NewBloomFilterWithSize(m, k, hash)
+}
+
+// bloomShape returns the optimal number of cells and hash functions for n
+// items at false-positive rate p.
+func bloomShape(n int, p float64) (uint64, int) {
+	if !(p > 0 && p < 1) {
+		panic(fmt.Sprintf("probabilistic: false-positive rate %v out of range (0, 1)", p))
+	}
+	items := float64(max(n, 1))
+	m := math.Ceil(-items * math.Log(p) / (math.Ln2 * math.Ln2))
+	return uint64(m), int(math.Round(m / items * math.Ln2))
+}
+
+// NewBloomFilterWithSize creates a filter of m bits using k hash functions.
+// m is rounded up to a multiple of 64 and k is clamped to at least 1.
+func NewBloomFilterWithSize[T any](m uint64, k int, hash collections.Hasher[T]) *BloomFilter[T] {
+	words := max((m+63)/64, 1)
+	return &BloomFilter[T]{bits: make([]uint64, words), m: words * 64, k: max(k, 1), hash: hash}
+}
+
+// Add records v in the filter.
+func (f *BloomFilter[T]) Add(v T) {
+	if f == nil {
+		return
+	}
+	h1, h2 := splitHash(f.hash(v))
+	for i := 0; i < f.k; i++ {
+		j := index(h1+uint64(i)*h2, f.m)
+		f.bits[j/64] |= 1 << (j % 64)
+	}
+}
+
+// Has reports whether v may have been added. A false result is definite.
+func (f *BloomFilter[T]) Has(v T) bool {
+	if f == nil {
+		return false
+	}
+	h1, h2 := splitHash(f.hash(v))
+	for i := 0; i < f.k; i++ {
+		j := index(h1+uint64(i)*h2, f.m)
+		if f.bits[j/64]&(1<<(j%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear resets the filter to empty.
+func (f *BloomFilter[T]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.bits)
+}
+
+// BitCount returns the number of bits in the filter.
+func (f *BloomFilter[T]) BitCount() uint64 {
+	if f == nil {
+		return 0
+	}
+	return f.m
+}
+
+// HashCount returns the number of hash functions applied to each item.
+func (f *BloomFilter[T]) HashCount() int {
+	if f == nil {
+		return 0
+	}
+	return f.k
+}
+
+// FillRatio returns the fraction of bits that are set. A filter loaded with
+// its expected number of items is about half full.
+func (f *BloomFilter[T]) FillRatio() float64 {
+	if f == nil {
+		return 0
+	}
+	return float64(popcount(f.bits)) / float64(f.m)
+}
+
+// EstimatedCount estimates the number of distinct items added from the fill
+// ratio.
+func (f *BloomFilter[T]) EstimatedCount() int {
+	fill := f.FillRatio()
+	if fill == 0 {
+		return 0
+	}
+	if fill == 1 {
+		return math.MaxInt
+	}
+	return int(math.Round(-float64(f.m) / float64(f.k) * math.Log1p(-fill)))
+}
+
+// EstimatedFalsePositiveRate estimates the current false-positive rate from
+// the fill ratio. It is 0 for a nil or empty filter.
+func (f *BloomFilter[T]) EstimatedFalsePositiveRate() float64 {
+	fill := f.FillRatio()
+	if fill == 0 {
+		return 0
+	}
+	return math.Pow(fill, float64(f.k))
+}
+
+// ErrIncompatible is returned when combining filters of different shapes.
+var ErrIncompatible = errors.New("probabilistic: filters have different sizes or hash counts")
+
+// Union returns a new filter holding the items of f and other, which must
+// have the same size and hash count and use the same hasher.
+func (f *BloomFilter[T]) Union(other *BloomFilter[T]) (*BloomFilter[T], error) {
+	if err := f.compatible(other); err != nil {
+		return nil, err
+	}
+	out := &BloomFilter[T]{bits: append([]uint64(nil), f.bits...), m: f.m, k: f.k, hash: f.hash}
+	out.UnionWith(other)
+	return out, nil
+}
+
+// UnionWith adds the items of other to f. The filters must have the same
+// size and hash count and use the same hasher.
+func (f *BloomFilter[T]) UnionWith(other *BloomFilter[T]) error {
+	if err := f.compatible(other); err != nil {
+		return err
+	}
+	for i, w := range other.bits {
+		f.bits[i] |= w
+	}
+	return nil
+}
+
+func (f *BloomFilter[T]) compatible(other *BloomFilter[T]) error {
+	if f == nil || other == nil || f.m != other.m || f.k != other.k {
+		return ErrIncompatible
+	}
+	return nil
+}
+
+const bloomMagic = "BLM1"
+
+// MarshalBinary encodes the filter's size, hash count and bits. The hasher
+// is not encoded; the receiving side must use the same one.
+func (f *BloomFilter[T]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("probabilistic: MarshalBinary on nil *BloomFilter")
+	}
+	data := make([]byte, 0, len(bloomMagic)+4+8*len(f.bits))
+	data = append(data, bloomMagic...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(f.k))
+	for _, w := range f.bits {
+		data = binary.LittleEndian.AppendUint64(data, w)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the filter's size, hash count and bits with data
+// produced by MarshalBinary, keeping the receiver's hasher.
+func (f *BloomFilter[T]) UnmarshalBinary(data []byte) error {
+	if f == nil {
+		return errors.New("probabilistic: UnmarshalBinary on nil *BloomFilter")
+	}
+	header := len(bloomMagic) + 4
+	if len(data) < header+8 || string(data[:len(bloomMagic)]) != bloomMagic || (len(data)-header)%8 != 0 {
+		return errors.New("probabilistic: malformed BloomFilter data")
+	}
+	k := binary.LittleEndian.Uint32(data[len(bloomMagic):])
+	if k == 0 || k > 1<<16 {
+		return errors.New("probabilistic: malformed BloomFilter data")
+	}
+	words := make([]uint64, (len(data)-header)/8)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[header+8*i:])
+	}
+	f.bits, f.m, f.k = words, uint64(len(words))*64, int(k)
+	return nil
+}
+
+// splitHash derives the two hashes used for double hashing from one 64-bit
+// hash. The second is remixed so that weak hashers still spread well, and
+// made odd so that the probe sequence h1 + i*h2 does not cycle early; the
+// bit positions it maps to can still coincide, which only wastes a probe.
+func splitHash(h uint64) (uint64, uint64) {
+	return h, mix64(h) | 1
+}
+
+// mix64 is the SplitMix64 finalizer.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// index maps h uniformly onto [0, n) without a division.
+func index(h, n uint64) uint64 {
+	hi, _ := bits.Mul64(mix64(h), n)
+	return hi
+}
+
+func popcount(words []uint64) int {
+	n := 0
+	for _, w := range words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package probabilistic
+
+import (
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+)
+
+func BenchmarkBloomFilter_Add(b *testing.B) {
+	f := NewBloomFilter[uint64](1<<20, 0.01, concurrent.Uint64Hasher)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(uint64(i))
+	}
+}
+
+func BenchmarkBloomFilter_Has(b *testing.B) {
+	f := NewBloomFilter[uint64](1<<20, 0.01, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 1<<20; i++ {
+		f.Add(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.Has(uint64(i))
+	}
+}
+
+func BenchmarkCountingBloomFilter_AddRemove(b *testing.B) {
+	f := NewCountingBloomFilter[uint64](1<<20, 0.01, concurrent.Uint64Hasher)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.Add(uint64(i))
+		f.Remove(uint64(i))
+	}
+}
+
+func BenchmarkCuckooFilter_Add(b *testing.B) {
+	f := NewCuckooFilter[uint64](b.N, 12, 0.95, concurrent.Uint64Hasher)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.Add(uint64(i))
+	}
+}
+
+func BenchmarkCuckooFilter_Has(b *testing.B) {
+	f := NewCuckooFilter[uint64](1<<20, 12, 0.95, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 1<<20; i++ {
+		_ = f.Add(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.Has(uint64(i))
+	}
+}
@@ -0,0 +1,147 @@
+package probabilistic_test
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	f := probabilistic.NewBloomFilter[string](1000, 0.01, concurrent.StringHasher)
+	for i := 0; i < 1000; i++ {
+		f.Add("item:" + strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.Has("item:" + strconv.Itoa(i)) {
+			t.Fatalf("false negative for item:%d", i)
+		}
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const n, p = 10000, 0.01
+	f := probabilistic.NewBloomFilter[uint64](n, p, concurrent.Uint64Hasher)
+	for i := uint64(0); i < n; i++ {
+		f.Add(i)
+	}
+	fp := 0
+	for i := uint64(n); i < 11*n; i++ {
+		if f.Has(i) {
+			fp++
+		}
+	}
+	rate := float64(fp) / (10 * n)
+	if rate > 2*p {
+		t.Fatalf("false-positive rate %.4f, want about %.2f", rate, p)
+	}
+	if est := f.EstimatedFalsePositiveRate(); math.Abs(est-p) > p/2 {
+		t.Fatalf("EstimatedFalsePositiveRate %.4f, want about %.2f", est, p)
+	}
+	if fill := f.FillRatio(); fill < 0.4 || fill > 0.6 {
+		t.Fatalf("FillRatio %.2f, want about 0.5", fill)
+	}
+	if c := f.EstimatedCount(); c < n*95/100 || c > n*105/100 {
+		t.Fatalf("EstimatedCount %d, want about %d", c, n)
+	}
+}
+
+func TestBloomFilterSizing(t *testing.T) {
+	f := probabilistic.NewBloomFilter[string](1000, 0.01, concurrent.StringHasher)
+	if f.BitCount() < 9585 || f.BitCount()%64 != 0 || f.HashCount() != 7 {
+		t.Fatalf("BitCount %d HashCount %d", f.BitCount(), f.HashCount())
+	}
+	g := probabilistic.NewBloomFilterWithSize[string](1, 0, concurrent.StringHasher)
+	if g.BitCount() != 64 || g.HashCount() != 1 {
+		t.Fatalf("BitCount %d HashCount %d", g.BitCount(), g.HashCount())
+	}
+	for _, p := range []float64{0, 1, -1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("rate %v did not panic", p)
+				}
+			}()
+			probabilistic.NewBloomFilter[string](10, p, concurrent.StringHasher)
+		}()
+	}
+}
+
+func TestBloomFilterUnion(t *testing.T) {
+	a := probabilistic.NewBloomFilter[string](100, 0.01, concurrent.StringHasher)
+	b := probabilistic.NewBloomFilter[string](100, 0.01, concurrent.StringHasher)
+	a.Add("a")
+	b.Add("b")
+	u, err := a.Union(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.Has("a") || !u.Has("b") {
+		t.Fatalf("union missing items")
+	}
+	if a.Has("b") {
+		t.Fatalf("Union modified receiver")
+	}
+	if err := a.UnionWith(b); err != nil || !a.Has("b") {
+		t.Fatalf("UnionWith: %v", err)
+	}
+
+	c := probabilistic.NewBloomFilter[string](1000, 0.01, concurrent.StringHasher)
+	if _, err := a.Union(c); !errors.Is(err, probabilistic.ErrIncompatible) {
+		t.Fatalf("Union of different sizes: %v", err)
+	}
+	if err := a.UnionWith(nil); !errors.Is(err, probabilistic.ErrIncompatible) {
+		t.Fatalf("UnionWith nil: %v", err)
+	}
+}
+
+func TestBloomFilterBinary(t *testing.T) {
+	f := probabilistic.NewBloomFilter[string](500, 0.001, concurrent.StringHasher)
+	for i := 0; i < 500; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := probabilistic.NewBloomFilterWithSize[string](64, 1, concurrent.StringHasher)
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.BitCount() != f.BitCount() || g.HashCount() != f.HashCount() || g.FillRatio() != f.FillRatio() {
+		t.Fatalf("round trip changed shape")
+	}
+	for i := 0; i < 500; i++ {
+		if !g.Has(strconv.Itoa(i)) {
+			t.Fatalf("decoded filter missing %d", i)
+		}
+	}
+
+	for _, bad := range [][]byte{nil, []byte("BLM1"), append([]byte("XXXX\x01\x00\x00\x00"), make([]byte, 8)...), data[:len(data)-3]} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("UnmarshalBinary(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestBloomFilterClearAndNil(t *testing.T) {
+	f := probabilistic.NewBloomFilter[string](10, 0.1, concurrent.StringHasher)
+	f.Add("x")
+	f.Clear()
+	if f.Has("x") || f.FillRatio() != 0 || f.EstimatedCount() != 0 || f.EstimatedFalsePositiveRate() != 0 {
+		t.Fatalf("Clear left bits set")
+	}
+
+	var n *probabilistic.BloomFilter[string]
+	n.Add("x")
+	n.Clear()
+	if n.Has("x") || n.BitCount() != 0 || n.HashCount() != 0 || n.FillRatio() != 0 || n.EstimatedCount() != 0 || n.EstimatedFalsePositiveRate() != 0 {
+		t.Fatalf("nil filter not empty")
+	}
+	if _, err := n.MarshalBinary(); err == nil {
+		t.Fatalf("MarshalBinary on nil succeeded")
+	}
+}
@@ -0,0 +1,184 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// maxCount is the largest value a 4-bit counter can hold.
+const maxCount = 15
+
+// CountingBloomFilter is a Bloom filter that supports removal.
+//
+// Each bit of a BloomFilter is replaced by a 4-bit counter, so the filter
+// uses four times the memory of a BloomFilter with the same false-positive
+// rate. Add fails with ErrFilterFull rather than overflow a counter, which
+// keeps Remove exact; with the expected number of distinct items this only
+// happens when the same item is added many times.
+//
+// Remove must only be called for items that were added: removing an item
+// that merely tests positive clears counters belonging to other items and
+// can introduce false negatives.
+//
+// A CountingBloomFilter must be created with NewCountingBloomFilter or
+// NewCountingBloomFilterWithSize; methods on a nil *CountingBloomFilter
+// behave like an empty filter.
+type CountingBloomFilter[T any] struct {
+	counters []byte // two counters per byte, low nibble first
+	m        uint64 // number of counters
+	k        int    // number of hash functions
+	hash     collections.Hasher[T]
+}
+
+// NewCountingBloomFilter creates a filter sized to hold expectedItems with
+// the given false-positive rate, which must be between 0 and 1 exclusive.
+func NewCountingBloomFilter[T any](expectedItems int, falsePositiveRate float64, hash collections.Hasher[T]) *CountingBloomFilter[T] {
+	m, k := bloomShape(expectedItems, falsePositiveRate)
+	return NewCountingBloomFilterWithSize(m, k, hash)
+}
+
+// NewCountingBloomFilterWithSize creates a filter of m counters using k
+// hash functions. m is rounded up to an even number and k is clamped to at
+// least 1.
+func NewCountingBloomFilterWithSize[T any](m uint64, k int, hash collections.Hasher[T]) *CountingBloomFilter[T] {
+	n := max((m+1)/2, 1)
+	return &CountingBloomFilter[T]{counters: make([]byte, n), m: n * 2, k: max(k, 1), hash: hash}
+}
+
+// Add records v in the filter. It returns ErrFilterFull, leaving the filter
+// unchanged, if one of v's counters is saturated.
+func (f *CountingBloomFilter[T]) Add(v T) error {
+	if f == nil {
+		return ErrFilterFull
+	}
+	h1, h2 := splitHash(f.hash(v))
+	for i := 0; i < f.k; i++ {
+		j := index(h1+uint64(i)*h2, f.m)
+		if f.count(j) == maxCount {
+			// Undo the increments made so far.
+			for i--; i >= 0; i-- {
+				j := index(h1+uint64(i)*h2, f.m)
+				f.setCount(j, f.count(j)-1)
+			}
+			return ErrFilterFull
+		}
+		f.setCount(j, f.count(j)+1)
+	}
+	return nil
+}
+
+// Has reports whether v may have been added. A false result is definite.
+func (f *CountingBloomFilter[T]) Has(v T) bool {
+	if f == nil {
+		return false
+	}
+	h1, h2 := splitHash(f.hash(v))
+	for i := 0; i < f.k; i++ {
+		if f.count(index(h1+uint64(i)*h2, f.m)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove deletes one occurrence of v and reports whether v tested positive.
+// If v definitely was not added the filter is unchanged.
+func (f *CountingBloomFilter[T]) Remove(v T) bool {
+	if !f.Has(v) {
+		return false
+	}
+	h1, h2 := splitHash(f.hash(v))
+	for i := 0; i < f.k; i++ {
+		j := index(h1+uint64(i)*h2, f.m)
+		// Only reachable at zero if v was never actually added.
+		if c := f.count(j); c > 0 {
+			f.setCount(j, c-1)
+		}
+	}
+	return true
+}
+
+// Clear resets the filter to empty.
+func (f *CountingBloomFilter[T]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.counters)
+}
+
+// CounterCount returns the number of counters in the filter.
+func (f *CountingBloomFilter[T]) CounterCount() uint64 {
+	if f == nil {
+		return 0
+	}
+	return f.m
+}
+
+// HashCount returns the number of hash functions applied to each item.
+func (f *CountingBloomFilter[T]) HashCount() int {
+	if f == nil {
+		return 0
+	}
+	return f.k
+}
+
+// FillRatio returns the fraction of counters that are non-zero.
+func (f *CountingBloomFilter[T]) FillRatio() float64 {
+	if f == nil {
+		return 0
+	}
+	n := 0
+	for _, b := range f.counters {
+		if b&0x0f != 0 {
+			n++
+		}
+		if b&0xf0 != 0 {
+			n++
+		}
+	}
+	return float64(n) / float64(f.m)
+}
+
+func (f *CountingBloomFilter[T]) count(j uint64) byte {
+	return f.counters[j/2] >> (4 * (j % 2)) & 0x0f
+}
+
+func (f *CountingBloomFilter[T]) setCount(j uint64, c byte) {
+	shift := 4 * (j % 2)
+	f.counters[j/2] = f.counters[j/2]&^(0x0f<<shift) | c<<shift
+}
+
+const countingMagic = "CBF1"
+
+// MarshalBinary encodes the filter's hash count and counters. The hasher is
+// not encoded; the receiving side must use the same one.
+func (f *CountingBloomFilter[T]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("probabilistic: MarshalBinary on nil *CountingBloomFilter")
+	}
+	data := make([]byte, 0, len(countingMagic)+4+len(f.counters))
+	data = append(data, countingMagic...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(f.k))
+	return append(data, f.counters...), nil
+}
+
+// UnmarshalBinary replaces the filter's size, hash count and counters with
+// data produced by MarshalBinary, keeping the receiver's hasher.
+func (f *CountingBloomFilter[T]) UnmarshalBinary(data []byte) error {
+	if f == nil {
+		return errors.New("probabilistic: UnmarshalBinary on nil *CountingBloomFilter")
+	}
+	header := len(countingMagic) + 4
+	if len(data) <= header || string(data[:len(countingMagic)]) != countingMagic {
+		return errors.New("probabilistic: malformed CountingBloomFilter data")
+	}
+	k := binary.LittleEndian.Uint32(data[len(countingMagic):])
+	if k == 0 || k > 1<<16 {
+		return errors.New("probabilistic: malformed CountingBloomFilter data")
+	}
+	f.counters = append([]byte(nil), data[header:]...)
+	f.m, f.k = uint64(len(f.counters))*2, int(k)
+	return nil
+}
@@ -0,0 +1,99 @@
+package probabilistic_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+func TestCountingBloomFilterAddRemove(t *testing.T) {
+	f := probabilistic.NewCountingBloomFilter[string](1000, 0.01, concurrent.StringHasher)
+	for i := 0; i < 1000; i++ {
+		if err := f.Add("session:" + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		if !f.Remove("session:" + strconv.Itoa(i)) {
+			t.Fatalf("Remove(session:%d) = false", i)
+		}
+	}
+	for i := 500; i < 1000; i++ {
+		if !f.Has("session:" + strconv.Itoa(i)) {
+			t.Fatalf("false negative for session:%d after removals", i)
+		}
+	}
+	fp := 0
+	for i := 0; i < 500; i++ {
+		if f.Has("session:" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	if fp > 25 {
+		t.Fatalf("%d of 500 removed items still present", fp)
+	}
+	for i := 500; i < 1000; i++ {
+		f.Remove("session:" + strconv.Itoa(i))
+	}
+	if f.FillRatio() != 0 {
+		t.Fatalf("FillRatio %v after removing everything", f.FillRatio())
+	}
+	if f.Remove("missing") {
+		t.Fatalf("Remove of absent item = true")
+	}
+}
+
+func TestCountingBloomFilterSaturation(t *testing.T) {
+	f := probabilistic.NewCountingBloomFilterWithSize[string](64, 3, concurrent.StringHasher)
+	for i := 0; i < 15; i++ {
+		if err := f.Add("x"); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	if err := f.Add("x"); !errors.Is(err, probabilistic.ErrFilterFull) {
+		t.Fatalf("Add past saturation: %v", err)
+	}
+	for i := 0; i < 15; i++ {
+		if !f.Remove("x") {
+			t.Fatalf("Remove %d = false", i)
+		}
+	}
+	if f.Has("x") {
+		t.Fatalf("failed Add left counters behind")
+	}
+}
+
+func TestCountingBloomFilterBinary(t *testing.T) {
+	f := probabilistic.NewCountingBloomFilter[string](100, 0.01, concurrent.StringHasher)
+	f.Add("a")
+	f.Add("a")
+	f.Add("b")
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := probabilistic.NewCountingBloomFilterWithSize[string](2, 1, concurrent.StringHasher)
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.CounterCount() != f.CounterCount() || g.HashCount() != f.HashCount() {
+		t.Fatalf("round trip changed shape")
+	}
+	g.Remove("a")
+	if !g.Has("a") || !g.Has("b") {
+		t.Fatalf("decoded filter lost counts")
+	}
+	for _, bad := range [][]byte{nil, []byte("CBF1\x01\x00\x00\x00"), []byte("CBF1\x00\x00\x00\x00\x00"), []byte("XXXX\x01\x00\x00\x00\x00")} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("UnmarshalBinary(%q) succeeded", bad)
+		}
+	}
+
+	var n *probabilistic.CountingBloomFilter[string]
+	if n.Has("a") || n.Remove("a") || n.Add("a") == nil || n.CounterCount() != 0 {
+		t.Fatalf("nil filter not empty")
+	}
+}
@@ -0,0 +1,180 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// CountMinSketch estimates how often each item has been added.
+//
+// The sketch keeps depth rows of width counters; an item increments one
+// counter per row and its count is the smallest of those counters. Counts
+// are never underestimated. A sketch created with NewCountMinSketch(epsilon,
+// delta, ...) overestimates a count by more than epsilon times Total with
+// probability at most delta.
+//
+// Add uses conservative update, raising only the counters that are below the
+// item's new estimate, which gives noticeably smaller overestimates for
+// skewed workloads than incrementing every row. Sketches of the same shape
+// and hasher can be merged by adding their counters; the result still never
+// underestimates.
+//
+// A CountMinSketch must be created with NewCountMinSketch or
+// NewCountMinSketchWithSize; methods on a nil *CountMinSketch behave like
+// an empty sketch.
+type CountMinSketch[T any] struct {
+	counts []uint64 // depth rows of width counters
+	width  uint64
+	depth  int
+	total  uint64
+	hash   collections.Hasher[T]
+}
+
+// NewCountMinSketch creates a sketch whose estimates exceed the true count
+// by at most epsilon*Total with probability 1-delta. Both must be between 0
+// and 1 exclusive.
+func NewCountMinSketch[T any](epsilon, delta float64, hash collections.Hasher[T]) *CountMinSketch[T] {
+	if !(epsilon > 0 && epsilon < 1) || !(delta > 0 && delta < 1) {
+		panic(fmt.Sprintf("probabilistic: epsilon %v or delta %v out of range (0, 1)", epsilon, delta))
+	}
+	width := math.Ceil(math.E / epsilon)
+	depth := math.Ceil(math.Log(1 / delta))
+	return NewCountMinSketchWithSize(uint64(width), int(depth), hash)
+}
+
+// NewCountMinSketchWithSize creates a sketch of depth rows of width
+// counters. Both are clamped to at least 1.
+func NewCountMinSketchWithSize[T any](width uint64, depth int, hash collections.Hasher[T]) *CountMinSketch[T] {
+	width, depth = max(width, 1), max(depth, 1)
+	return &CountMinSketch[T]{counts: make([]uint64, width*uint64(depth)), width: width, depth: depth, hash: hash}
+}
+
+// Add records one occurrence of v.
+func (s *CountMinSketch[T]) Add(v T) {
+	s.AddCount(v, 1)
+}
+
+// AddCount records n occurrences of v.
+func (s *CountMinSketch[T]) AddCount(v T, n uint64) {
+	if s == nil || n == 0 {
+		return
+	}
+	h1, h2 := splitHash(s.hash(v))
+	est := s.estimate(h1, h2) + n
+	for i := 0; i < s.depth; i++ {
+		j := uint64(i)*s.width + index(h1+uint64(i)*h2, s.width)
+		s.counts[j] = max(s.counts[j], est)
+	}
+	s.total += n
+}
+
+// Count returns the estimated number of occurrences of v. It is never less
+// than the true count.
+func (s *CountMinSketch[T]) Count(v T) uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.estimate(splitHash(s.hash(v)))
+}
+
+func (s *CountMinSketch[T]) estimate(h1, h2 uint64) uint64 {
+	est := uint64(math.MaxUint64)
+	for i := 0; i < s.depth; i++ {
+		est = min(est, s.counts[uint64(i)*s.width+index(h1+uint64(i)*h2, s.width)])
+	}
+	return est
+}
+
+// Total returns the number of occurrences added across all items.
+func (s *CountMinSketch[T]) Total() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.total
+}
+
+// Width returns the number of counters per row.
+func (s *CountMinSketch[T]) Width() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.width
+}
+
+// Depth returns the number of rows.
+func (s *CountMinSketch[T]) Depth() int {
+	if s == nil {
+		return 0
+	}
+	return s.depth
+}
+
+// Clear resets all counts to zero.
+func (s *CountMinSketch[T]) Clear() {
+	if s == nil {
+		return
+	}
+	clear(s.counts)
+	s.total = 0
+}
+
+// Merge adds the counts of other to s. Both sketches must have the same
+// width and depth and use the same hasher.
+func (s *CountMinSketch[T]) Merge(other *CountMinSketch[T]) error {
+	if s == nil || other == nil || s.width != other.width || s.depth != other.depth {
+		return ErrIncompatible
+	}
+	for i, c := range other.counts {
+		s.counts[i] += c
+	}
+	s.total += other.total
+	return nil
+}
+
+const countMinMagic = "CMS1"
+
+// MarshalBinary encodes the sketch's shape, total and counters. The hasher
+// is not encoded; the receiving side must use the same one.
+func (s *CountMinSketch[T]) MarshalBinary() ([]byte, error) {
+	if s == nil {
+		return nil, errors.New("probabilistic: MarshalBinary on nil *CountMinSketch")
+	}
+	data := make([]byte, 0, len(countMinMagic)+24+8*len(s.counts))
+	data = append(data, countMinMagic...)
+	data = binary.LittleEndian.AppendUint64(data, s.width)
+	data = binary.LittleEndian.AppendUint64(data, uint64(s.depth))
+	data = binary.LittleEndian.AppendUint64(data, s.total)
+	for _, c := range s.counts {
+		data = binary.LittleEndian.AppendUint64(data, c)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the sketch with data produced by MarshalBinary,
+// keeping the receiver's hasher.
+func (s *CountMinSketch[T]) UnmarshalBinary(data []byte) error {
+	if s == nil {
+		return errors.New("probabilistic: UnmarshalBinary on nil *CountMinSketch")
+	}
+	bad := errors.New("probabilistic: malformed CountMinSketch data")
+	const header = len(countMinMagic) + 24
+	if len(data) < header || string(data[:len(countMinMagic)]) != countMinMagic {
+		return bad
+	}
+	width := binary.LittleEndian.Uint64(data[4:])
+	depth := binary.LittleEndian.Uint64(data[12:])
+	cells := uint64(len(data)-header) / 8
+	if width == 0 || depth == 0 || depth > cells || width != cells/depth || width*depth*8 != uint64(len(data)-header) {
+		return bad
+	}
+	counts := make([]uint64, cells)
+	for i := range counts {
+		counts[i] = binary.LittleEndian.Uint64(data[header+8*i:])
+	}
+	s.counts, s.width, s.depth, s.total = counts, width, int(depth), binary.LittleEndian.Uint64(data[20:])
+	return nil
+}
@@ -0,0 +1,15 @@
+package probabilistic
+
+import (
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+)
+
+func BenchmarkCountMinSketch_Add(b *testing.B) {
+	s := NewCountMinSketch[uint64](0.001, 0.01, concurrent.Uint64Hasher)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(uint64(i % 10000))
+	}
+}
@@ -0,0 +1,139 @@
+package probabilistic_test
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+// zipfStream returns n keys in shuffled order whose frequencies follow a
+// Zipf distribution, along with their exact counts.
+func zipfStream(n int) ([]string, map[string]uint64) {
+	keys := make([]string, 0, n)
+	exact := make(map[string]uint64)
+	for rank := 1; len(keys) < n; rank++ {
+		k := "key:" + strconv.Itoa(rank)
+		for c := max(n/(10*rank), 1); c > 0 && len(keys) < n; c-- {
+			keys = append(keys, k)
+			exact[k]++
+		}
+	}
+	rand.New(rand.NewPCG(1, 2)).Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	return keys, exact
+}
+
+func TestCountMinSketchBounds(t *testing.T) {
+	const eps, delta = 0.001, 0.01
+	s := probabilistic.NewCountMinSketch[string](eps, delta, concurrent.StringHasher)
+	if s.Width() != uint64(math.Ceil(math.E/eps)) || s.Depth() != 5 {
+		t.Fatalf("Width %d Depth %d", s.Width(), s.Depth())
+	}
+	keys, exact := zipfStream(100000)
+	for _, k := range keys {
+		s.Add(k)
+	}
+	if s.Total() != uint64(len(keys)) {
+		t.Fatalf("Total %d", s.Total())
+	}
+	over := 0
+	for k, c := range exact {
+		est := s.Count(k)
+		if est < c {
+			t.Fatalf("Count(%s) = %d underestimates %d", k, est, c)
+		}
+		if float64(est-c) > eps*float64(s.Total()) {
+			over++
+		}
+	}
+	if float64(over) > 2*delta*float64(len(exact)) {
+		t.Fatalf("%d of %d counts exceed the error bound", over, len(exact))
+	}
+	if s.Count("key:1") != exact["key:1"] {
+		t.Fatalf("heavy hitter count %d, exact %d", s.Count("key:1"), exact["key:1"])
+	}
+}
+
+func TestCountMinSketchConservativeUpdate(t *testing.T) {
+	// Conservative update never raises a counter above the plain sum of the
+	// items hashed to it, so a second row can only tighten the estimates of
+	// a single-row sketch.
+	s := probabilistic.NewCountMinSketchWithSize[uint64](4, 2, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 100; i++ {
+		s.AddCount(i%8, i+1)
+	}
+	plain := probabilistic.NewCountMinSketchWithSize[uint64](4, 1, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 100; i++ {
+		plain.AddCount(i%8, i+1)
+	}
+	for i := uint64(0); i < 8; i++ {
+		if s.Count(i) > plain.Count(i) {
+			t.Fatalf("Count(%d) = %d exceeds single-row %d", i, s.Count(i), plain.Count(i))
+		}
+	}
+	s.AddCount(99, 0)
+	if s.Total() != 5050 {
+		t.Fatalf("Total %d", s.Total())
+	}
+}
+
+func TestCountMinSketchMerge(t *testing.T) {
+	a := probabilistic.NewCountMinSketch[string](0.01, 0.01, concurrent.StringHasher)
+	b := probabilistic.NewCountMinSketch[string](0.01, 0.01, concurrent.StringHasher)
+	a.AddCount("hot", 500)
+	b.AddCount("hot", 300)
+	b.Add("cold")
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count("hot") < 800 || a.Count("cold") < 1 || a.Total() != 801 {
+		t.Fatalf("merged counts hot=%d cold=%d total=%d", a.Count("hot"), a.Count("cold"), a.Total())
+	}
+	c := probabilistic.NewCountMinSketch[string](0.1, 0.01, concurrent.StringHasher)
+	if err := a.Merge(c); !errors.Is(err, probabilistic.ErrIncompatible) {
+		t.Fatalf("Merge of different shapes: %v", err)
+	}
+	a.Clear()
+	if a.Count("hot") != 0 || a.Total() != 0 {
+		t.Fatalf("Clear left counts")
+	}
+}
+
+func TestCountMinSketchBinary(t *testing.T) {
+	s := probabilistic.NewCountMinSketchWithSize[string](100, 3, concurrent.StringHasher)
+	keys, exact := zipfStream(5000)
+	for _, k := range keys {
+		s.Add(k)
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := probabilistic.NewCountMinSketchWithSize[string](1, 1, concurrent.StringHasher)
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Width() != 100 || g.Depth() != 3 || g.Total() != s.Total() {
+		t.Fatalf("round trip changed shape")
+	}
+	for k := range exact {
+		if g.Count(k) != s.Count(k) {
+			t.Fatalf("Count(%s) = %d after round trip, want %d", k, g.Count(k), s.Count(k))
+		}
+	}
+	for _, bad := range [][]byte{nil, data[:len(data)-8], data[:28]} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("UnmarshalBinary succeeded on bad data")
+		}
+	}
+
+	var n *probabilistic.CountMinSketch[string]
+	n.Add("a")
+	if n.Count("a") != 0 || n.Total() != 0 || n.Merge(s) == nil {
+		t.Fatalf("nil sketch not empty")
+	}
+}
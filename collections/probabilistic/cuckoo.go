@@ -0,0 +1,305 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// ErrFilterFull is returned when an item cannot be added to a filter.
+var ErrFilterFull = errors.New("probabilistic: filter is full")
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+)
+
+// CuckooFilter is a probabilistic set that supports removal.
+//
+// Each item is reduced to a short fingerprint stored in one of two candidate
+// buckets of four slots; inserting into two full buckets relocates existing
+// fingerprints to their alternate bucket. Add, Has and Remove are O(1); Add
+// may take longer as the filter approaches capacity and fails with
+// ErrFilterFull once no relocation succeeds.
+//
+// The false-positive rate is about 8/2^f for f-bit fingerprints, so 8 bits
+// give roughly 3% and 16 bits roughly 0.01%. Adding the same item more than
+// eight times fills both of its buckets. As with CountingBloomFilter,
+// Remove must only be called for items that were added.
+//
+// A CuckooFilter must be created with NewCuckooFilter; methods on a nil
+// *CuckooFilter behave like an empty filter.
+type CuckooFilter[T any] struct {
+	table   []uint64 // fingerprints packed fpBits apart; zero means empty
+	buckets uint64   // power of two
+	fpBits  uint
+	n       int
+	hash    collections.Hasher[T]
+
+	// victim holds a fingerprint evicted by a failed Add so that no added
+	// item is ever lost; the filter reports full while it is occupied.
+	victim      uint16
+	victimIndex uint64
+}
+
+// NewCuckooFilter creates a filter able to hold capacity items when filled
+// to loadFactor, using fingerprints of fingerprintBits bits. fingerprintBits
+// must be between 1 and 16 and loadFactor between 0 and 1; a loadFactor of
+// about 0.95 is reachable in practice.
+func NewCuckooFilter[T any](capacity, fingerprintBits int, loadFactor float64, hash collections.Hasher[T]) *CuckooFilter[T] {
+	if fingerprintBits < 1 || fingerprintBits > 16 {
+		panic(fmt.Sprintf("probabilistic: fingerprint size %d out of range [1, 16]", fingerprintBits))
+	}
+	if !(loadFactor > 0 && loadFactor <= 1) {
+		panic(fmt.Sprintf("probabilistic: load factor %v out of range (0, 1]", loadFactor))
+	}
+	slots := float64(max(capacity, 1)) / loadFactor
+	buckets := uint64(1) << bits.Len64(uint64(slots+cuckooBucketSize-1)/cuckooBucketSize-1)
+	f := &CuckooFilter[T]{buckets: buckets, fpBits: uint(fingerprintBits), hash: hash}
+	f.table = make([]uint64, f.words())
+	return f
+}
+
+// words returns the number of table words for the filter's shape.
+func (f *CuckooFilter[T]) words() uint64 {
+	return (f.buckets*cuckooBucketSize*uint64(f.fpBits) + 63) / 64
+}
+
+// Add records v in the filter. It returns ErrFilterFull, without recording
+// v, once the filter is full. The Add that fills the filter still succeeds:
+// the fingerprint left over by relocation is kept aside until a Remove frees
+// room for it.
+func (f *CuckooFilter[T]) Add(v T) error {
+	if f == nil || f.victim != 0 {
+		return ErrFilterFull
+	}
+	fp, i := f.locate(v)
+	f.n++
+	if f.insert(i, fp) || f.insert(f.alt(i, fp), fp) {
+		return nil
+	}
+	f.relocate(fp, i)
+	return nil
+}
+
+// relocate places fp, whose buckets are full, by evicting fingerprints to
+// their alternate buckets until one lands in a free slot. If none does, the
+// last evicted fingerprint becomes the victim.
+func (f *CuckooFilter[T]) relocate(fp uint16, i uint64) {
+	for kick := uint64(0); kick < cuckooMaxKicks; kick++ {
+		s := i*cuckooBucketSize + mix64(kick^uint64(fp))%cuckooBucketSize
+		evicted := f.slot(s)
+		f.setSlot(s, fp)
+		fp, i = evicted, f.alt(i, evicted)
+		if f.insert(i, fp) {
+			f.victim = 0
+			return
+		}
+	}
+	f.victim, f.victimIndex = fp, i
+}
+
+// Has reports whether v may have been added. A false result is definite.
+func (f *CuckooFilter[T]) Has(v T) bool {
+	if f == nil {
+		return false
+	}
+	fp, i1 := f.locate(v)
+	i2 := f.alt(i1, fp)
+	if f.contains(i1, fp) || f.contains(i2, fp) {
+		return true
+	}
+	return f.victim == fp && (f.victimIndex == i1 || f.victimIndex == i2)
+}
+
+// Remove deletes one occurrence of v and reports whether v tested positive.
+// If v definitely was not added the filter is unchanged.
+func (f *CuckooFilter[T]) Remove(v T) bool {
+	if f == nil {
+		return false
+	}
+	fp, i1 := f.locate(v)
+	i2 := f.alt(i1, fp)
+	switch {
+	case f.remove(i1, fp) || f.remove(i2, fp):
+		// A slot is free again; try to find the victim a home.
+		if f.victim != 0 && !f.insert(f.victimIndex, f.victim) {
+			f.relocate(f.victim, f.victimIndex)
+		} else {
+			f.victim = 0
+		}
+	case f.victim == fp && (f.victimIndex == i1 || f.victimIndex == i2):
+		f.victim = 0
+	default:
+		return false
+	}
+	f.n--
+	return true
+}
+
+// Len returns the number of items in the filter.
+func (f *CuckooFilter[T]) Len() int {
+	if f == nil {
+		return 0
+	}
+	return f.n
+}
+
+// Clear removes all items.
+func (f *CuckooFilter[T]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.table)
+	f.n, f.victim = 0, 0
+}
+
+// Capacity returns the number of fingerprint slots in the filter.
+func (f *CuckooFilter[T]) Capacity() int {
+	if f == nil {
+		return 0
+	}
+	return int(f.buckets * cuckooBucketSize)
+}
+
+// LoadFactor returns the fraction of slots in use.
+func (f *CuckooFilter[T]) LoadFactor() float64 {
+	if f == nil {
+		return 0
+	}
+	return float64(f.n) / float64(f.Capacity())
+}
+
+// FingerprintBits returns the size of the stored fingerprints in bits.
+func (f *CuckooFilter[T]) FingerprintBits() int {
+	if f == nil {
+		return 0
+	}
+	return int(f.fpBits)
+}
+
+// locate returns v's fingerprint and primary bucket. Both come from the
+// remixed hash so that weak hashers still spread well; zero is reserved for
+// empty slots.
+func (f *CuckooFilter[T]) locate(v T) (uint16, uint64) {
+	h := mix64(f.hash(v))
+	fp := uint16(h >> (64 - f.fpBits))
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, h & (f.buckets - 1)
+}
+
+// alt returns the other bucket for fingerprint fp stored in bucket i. It is
+// its own inverse, so a fingerprint can be moved without knowing its item.
+func (f *CuckooFilter[T]) alt(i uint64, fp uint16) uint64 {
+	return (i ^ mix64(uint64(fp))) & (f.buckets - 1)
+}
+
+func (f *CuckooFilter[T]) insert(i uint64, fp uint16) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if f.slot(s) == 0 {
+			f.setSlot(s, fp)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *CuckooFilter[T]) contains(i uint64, fp uint16) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if f.slot(s) == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *CuckooFilter[T]) remove(i uint64, fp uint16) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if f.slot(s) == fp {
+			f.setSlot(s, 0)
+			return true
+		}
+	}
+	return false
+}
+
+// slot returns the fingerprint in slot s, which may straddle two words.
+func (f *CuckooFilter[T]) slot(s uint64) uint16 {
+	p := s * uint64(f.fpBits)
+	w, off := p/64, p%64
+	x := f.table[w] >> off
+	if off+uint64(f.fpBits) > 64 {
+		x |= f.table[w+1] << (64 - off)
+	}
+	return uint16(x) & (1<<f.fpBits - 1)
+}
+
+func (f *CuckooFilter[T]) setSlot(s uint64, fp uint16) {
+	p := s * uint64(f.fpBits)
+	w, off := p/64, p%64
+	mask := uint64(1)<<f.fpBits - 1
+	f.table[w] = f.table[w]&^(mask<<off) | uint64(fp)<<off
+	if off+uint64(f.fpBits) > 64 {
+		f.table[w+1] = f.table[w+1]&^(mask>>(64-off)) | uint64(fp)>>(64-off)
+	}
+}
+
+const cuckooMagic = "CKF1"
+
+// MarshalBinary encodes the filter's shape, contents and item count. The
+// hasher is not encoded; the receiving side must use the same one.
+func (f *CuckooFilter[T]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("probabilistic: MarshalBinary on nil *CuckooFilter")
+	}
+	data := make([]byte, 0, len(cuckooMagic)+2+8+2+8+8*len(f.table))
+	data = append(data, cuckooMagic...)
+	data = append(data, byte(f.fpBits), byte(bits.TrailingZeros64(f.buckets)))
+	data = binary.LittleEndian.AppendUint64(data, uint64(f.n))
+	data = binary.LittleEndian.AppendUint16(data, f.victim)
+	data = binary.LittleEndian.AppendUint64(data, f.victimIndex)
+	for _, w := range f.table {
+		data = binary.LittleEndian.AppendUint64(data, w)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the filter's shape and contents with data
+// produced by MarshalBinary, keeping the receiver's hasher.
+func (f *CuckooFilter[T]) UnmarshalBinary(data []byte) error {
+	if f == nil {
+		return errors.New("probabilistic: UnmarshalBinary on nil *CuckooFilter")
+	}
+	bad := errors.New("probabilistic: malformed CuckooFilter data")
+	const header = len(cuckooMagic) + 2 + 8 + 2 + 8
+	if len(data) < header || string(data[:len(cuckooMagic)]) != cuckooMagic {
+		return bad
+	}
+	fpBits, logBuckets := uint(data[4]), uint(data[5])
+	if fpBits < 1 || fpBits > 16 || logBuckets > 40 {
+		return bad
+	}
+	g := CuckooFilter[T]{
+		buckets:     1 << logBuckets,
+		fpBits:      fpBits,
+		n:           int(binary.LittleEndian.Uint64(data[6:])),
+		victim:      binary.LittleEndian.Uint16(data[14:]),
+		victimIndex: binary.LittleEndian.Uint64(data[16:]),
+		hash:        f.hash,
+	}
+	if uint64(len(data)-header) != 8*g.words() || g.n < 0 || g.n > g.Capacity()+1 ||
+		g.victimIndex >= g.buckets || uint64(g.victim) >= 1<<fpBits {
+		return bad
+	}
+	g.table = make([]uint64, g.words())
+	for i := range g.table {
+		g.table[i] = binary.LittleEndian.Uint64(data[header+8*i:])
+	}
+	*f = g
+	return nil
+}
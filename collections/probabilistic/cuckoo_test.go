@@ -0,0 +1,144 @@
+package probabilistic_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+func TestCuckooFilterAddRemove(t *testing.T) {
+	for _, bits := range []int{7, 8, 12, 16} {
+		f := probabilistic.NewCuckooFilter[uint64](10000, bits, 0.9, concurrent.Uint64Hasher)
+		for i := uint64(0); i < 10000; i++ {
+			if err := f.Add(i); err != nil {
+				t.Fatalf("bits=%d Add(%d): %v", bits, i, err)
+			}
+		}
+		if f.Len() != 10000 || f.FingerprintBits() != bits {
+			t.Fatalf("bits=%d Len %d", bits, f.Len())
+		}
+		for i := uint64(0); i < 10000; i++ {
+			if !f.Has(i) {
+				t.Fatalf("bits=%d false negative for %d", bits, i)
+			}
+		}
+		for i := uint64(0); i < 10000; i += 2 {
+			if !f.Remove(i) {
+				t.Fatalf("bits=%d Remove(%d) = false", bits, i)
+			}
+		}
+		for i := uint64(1); i < 10000; i += 2 {
+			if !f.Has(i) {
+				t.Fatalf("bits=%d false negative for %d after removals", bits, i)
+			}
+		}
+		if f.Len() != 5000 {
+			t.Fatalf("bits=%d Len %d after removals", bits, f.Len())
+		}
+	}
+}
+
+func TestCuckooFilterFalsePositiveRate(t *testing.T) {
+	f := probabilistic.NewCuckooFilter[uint64](10000, 12, 0.95, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 10000; i++ {
+		f.Add(i)
+	}
+	fp := 0
+	for i := uint64(10000); i < 110000; i++ {
+		if f.Has(i) {
+			fp++
+		}
+	}
+	// 8/2^12 is about 0.2%.
+	if rate := float64(fp) / 100000; rate > 0.004 {
+		t.Fatalf("false-positive rate %.4f", rate)
+	}
+}
+
+func TestCuckooFilterFull(t *testing.T) {
+	f := probabilistic.NewCuckooFilter[uint64](64, 16, 1, concurrent.Uint64Hasher)
+	var added []uint64
+	var err error
+	for i := uint64(0); i < 1000; i++ {
+		if err = f.Add(i); err != nil {
+			break
+		}
+		added = append(added, i)
+	}
+	if !errors.Is(err, probabilistic.ErrFilterFull) {
+		t.Fatalf("filter never reported full")
+	}
+	if f.Len() != len(added) || f.Len() > f.Capacity()+1 {
+		t.Fatalf("Len %d, added %d, capacity %d", f.Len(), len(added), f.Capacity())
+	}
+	for _, v := range added {
+		if !f.Has(v) {
+			t.Fatalf("full filter lost %d", v)
+		}
+	}
+	f.Remove(added[0])
+	if err := f.Add(added[0]); err != nil {
+		t.Fatalf("Add after Remove: %v", err)
+	}
+	for _, v := range added {
+		if !f.Has(v) {
+			t.Fatalf("lost %d after Remove", v)
+		}
+	}
+	f.Clear()
+	if f.Len() != 0 || f.Has(added[1]) || f.Add(1) != nil {
+		t.Fatalf("Clear did not reset the filter")
+	}
+}
+
+func TestCuckooFilterBinary(t *testing.T) {
+	f := probabilistic.NewCuckooFilter[string](500, 12, 0.9, concurrent.StringHasher)
+	for i := 0; i < 500; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := probabilistic.NewCuckooFilter[string](1, 8, 0.5, concurrent.StringHasher)
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != 500 || g.Capacity() != f.Capacity() || g.FingerprintBits() != 12 {
+		t.Fatalf("round trip changed shape")
+	}
+	for i := 0; i < 500; i++ {
+		if !g.Has(strconv.Itoa(i)) {
+			t.Fatalf("decoded filter missing %d", i)
+		}
+	}
+	for _, bad := range [][]byte{nil, data[:len(data)-1], append([]byte("CKF1\x11"), data[5:]...)} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("UnmarshalBinary succeeded on bad data")
+		}
+	}
+
+	var n *probabilistic.CuckooFilter[string]
+	if n.Has("a") || n.Remove("a") || n.Add("a") == nil || n.Len() != 0 || n.Capacity() != 0 {
+		t.Fatalf("nil filter not empty")
+	}
+}
+
+func TestCuckooFilterPanics(t *testing.T) {
+	for _, c := range []struct {
+		bits int
+		load float64
+	}{{0, 0.9}, {17, 0.9}, {8, 0}, {8, 1.5}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("bits=%d load=%v did not panic", c.bits, c.load)
+				}
+			}()
+			probabilistic.NewCuckooFilter[string](10, c.bits, c.load, concurrent.StringHasher)
+		}()
+	}
+}
@@ -0,0 +1,19 @@
+// Package probabilistic provides compact data structures that answer
+// membership and frequency questions approximately.
+//
+// Where a collections.Set of every seen item grows without bound, the types
+// in this package use a fixed amount of memory chosen up front and trade
+// exactness for size: a BloomFilter may report an item it never saw as
+// present, but never misses one it did see. CountingBloomFilter and
+// CuckooFilter additionally support Remove, for sets whose members expire.
+// HyperLogLog estimates how many distinct items were added, CountMinSketch
+// how often each was added, and TopK which were added most often.
+//
+// Items are hashed with a collections.Hasher, the same func(T) uint64 shape
+// as concurrent.Hasher, so existing hashers such as concurrent.StringHasher
+// and collections.BytesHasher can be passed directly. Structures that are
+// combined or exchanged between processes must use the same hasher.
+//
+// Like the root package, these types are not safe for concurrent use without
+// external synchronization.
+package probabilistic
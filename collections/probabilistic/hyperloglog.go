@@ -0,0 +1,290 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// HyperLogLog estimates the number of distinct items added to it.
+//
+// A sketch of precision p keeps 2^p small registers and estimates
+// cardinality with a relative standard error of about 1.04/sqrt(2^p): 1.6%
+// at p = 12 (4 KiB) and 0.8% at p = 14 (16 KiB), regardless of how many items
+// are added. Sketches with the same precision and hasher can be merged, so
+// per-shard or per-process counts combine into the count of their union.
+//
+// A new sketch starts in a sparse representation that only stores non-zero
+// registers, and switches to a dense array once that would be smaller.
+//
+// A HyperLogLog must be created with NewHyperLogLog; methods on a nil
+// *HyperLogLog behave like an empty sketch.
+type HyperLogLog[T any] struct {
+	p      uint8
+	dense  []uint8          // 2^p registers, nil while sparse
+	sparse map[uint32]uint8 // non-zero registers while sparse
+	hash   collections.Hasher[T]
+}
+
+// Precision bounds accepted by NewHyperLogLog.
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// NewHyperLogLog creates an empty sketch with 2^precision registers.
+// precision must be between MinPrecision and MaxPrecision.
+func NewHyperLogLog[T any](precision int, hash collections.Hasher[T]) *HyperLogLog[T] {
+	if precision < MinPrecision || precision > MaxPrecision {
+		panic(fmt.Sprintf("probabilistic: precision %d out of range [%d, %d]", precision, MinPrecision, MaxPrecision))
+	}
+	return &HyperLogLog[T]{p: uint8(precision), sparse: make(map[uint32]uint8), hash: hash}
+}
+
+// Add records v in the sketch.
+func (h *HyperLogLog[T]) Add(v T) {
+	if h == nil {
+		return
+	}
+	x := mix64(h.hash(v))
+	idx := uint32(x >> (64 - h.p))
+	// The remaining 64-p bits give the register value: one more than the
+	// number of leading zeros, so at most 64-p+1.
+	rho := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1)) + 1)
+	h.set(idx, rho)
+}
+
+func (h *HyperLogLog[T]) set(idx uint32, rho uint8) {
+	if h.dense != nil {
+		h.dense[idx] = max(h.dense[idx], rho)
+		return
+	}
+	if rho > h.sparse[idx] {
+		h.sparse[idx] = rho
+		if len(h.sparse) > h.sparseLimit() {
+			h.densify()
+		}
+	}
+}
+
+// sparseLimit is the number of sparse entries at which a map, at roughly 16
+// bytes per entry, outgrows the dense array of one byte per register.
+func (h *HyperLogLog[T]) sparseLimit() int {
+	return 1 << h.p / 16
+}
+
+func (h *HyperLogLog[T]) densify() {
+	h.dense = make([]uint8, 1<<h.p)
+	for idx, rho := range h.sparse {
+		h.dense[idx] = rho
+	}
+	h.sparse = nil
+}
+
+// Estimate returns the estimated number of distinct items added.
+func (h *HyperLogLog[T]) Estimate() uint64 {
+	if h == nil {
+		return 0
+	}
+	// Histogram of register values; see Ertl, "New cardinality estimation
+	// algorithms for HyperLogLog sketches" (2017), which needs neither bias
+	// tables nor a switch to linear counting for small cardinalities.
+	q := 64 - int(h.p)
+	m := float64(uint64(1) << h.p)
+	counts := make([]float64, q+2)
+	if h.dense != nil {
+		for _, r := range h.dense {
+			counts[r]++
+		}
+	} else {
+		counts[0] = m - float64(len(h.sparse))
+		for _, r := range h.sparse {
+			counts[r]++
+		}
+	}
+	if counts[0] == m {
+		return 0
+	}
+	z := m * hllTau(1-counts[q+1]/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + counts[k])
+	}
+	z += m * hllSigma(counts[0]/m)
+	return uint64(math.Round(m * m / (2 * math.Ln2) / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// Merge folds other into h, so that h estimates the number of distinct
+// items added to either. Both sketches must have the same precision and
+// use the same hasher.
+func (h *HyperLogLog[T]) Merge(other *HyperLogLog[T]) error {
+	if h == nil || other == nil || h.p != other.p {
+		return ErrIncompatible
+	}
+	if other.dense != nil {
+		if h.dense == nil {
+			h.densify()
+		}
+		for i, r := range other.dense {
+			h.dense[i] = max(h.dense[i], r)
+		}
+		return nil
+	}
+	for idx, rho := range other.sparse {
+		h.set(idx, rho)
+	}
+	return nil
+}
+
+// Clone returns an independent copy of the sketch.
+func (h *HyperLogLog[T]) Clone() *HyperLogLog[T] {
+	if h == nil {
+		return nil
+	}
+	out := &HyperLogLog[T]{p: h.p, hash: h.hash}
+	if h.dense != nil {
+		out.dense = slices.Clone(h.dense)
+	} else {
+		out.sparse = make(map[uint32]uint8, len(h.sparse))
+		for idx, rho := range h.sparse {
+			out.sparse[idx] = rho
+		}
+	}
+	return out
+}
+
+// Clear resets the sketch to empty.
+func (h *HyperLogLog[T]) Clear() {
+	if h == nil {
+		return
+	}
+	h.dense, h.sparse = nil, make(map[uint32]uint8)
+}
+
+// Precision returns the sketch's precision p; it has 2^p registers.
+func (h *HyperLogLog[T]) Precision() int {
+	if h == nil {
+		return 0
+	}
+	return int(h.p)
+}
+
+const (
+	hllMagic  = "HLL1"
+	hllSparse = 0
+	hllDense  = 1
+)
+
+// MarshalBinary encodes the sketch. The encoding depends only on the
+// registers, not on the order items were added, so equal sketches encode
+// identically. The hasher is not encoded; the receiving side must use the
+// same one.
+func (h *HyperLogLog[T]) MarshalBinary() ([]byte, error) {
+	if h == nil {
+		return nil, errors.New("probabilistic: MarshalBinary on nil *HyperLogLog")
+	}
+	data := append([]byte(hllMagic), h.p)
+	if h.dense != nil {
+		data = append(data, hllDense)
+		return append(data, h.dense...), nil
+	}
+	// Sparse entries as (index, value) pairs in index order.
+	data = append(data, hllSparse)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(h.sparse)))
+	idxs := make([]uint32, 0, len(h.sparse))
+	for idx := range h.sparse {
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+	for _, idx := range idxs {
+		data = binary.LittleEndian.AppendUint32(data, idx)
+		data = append(data, h.sparse[idx])
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the sketch with data produced by MarshalBinary,
+// keeping the receiver's hasher.
+func (h *HyperLogLog[T]) UnmarshalBinary(data []byte) error {
+	if h == nil {
+		return errors.New("probabilistic: UnmarshalBinary on nil *HyperLogLog")
+	}
+	bad := errors.New("probabilistic: malformed HyperLogLog data")
+	if len(data) < len(hllMagic)+2 || string(data[:len(hllMagic)]) != hllMagic {
+		return bad
+	}
+	p := data[4]
+	if p < MinPrecision || p > MaxPrecision {
+		return bad
+	}
+	m, maxRho := 1<<p, 64-p+1
+	g := HyperLogLog[T]{p: p, hash: h.hash}
+	body := data[6:]
+	switch data[5] {
+	case hllDense:
+		if len(body) != m || slices.Max(body) > maxRho {
+			return bad
+		}
+		g.dense = slices.Clone(body)
+	case hllSparse:
+		if len(body) < 4 {
+			return bad
+		}
+		n := int(binary.LittleEndian.Uint32(body))
+		body = body[4:]
+		if n > m || len(body) != 5*n {
+			return bad
+		}
+		g.sparse = make(map[uint32]uint8, n)
+		for i := 0; i < n; i++ {
+			idx, rho := binary.LittleEndian.Uint32(body[5*i:]), body[5*i+4]
+			if idx >= uint32(m) || rho == 0 || rho > maxRho || (i > 0 && idx <= binary.LittleEndian.Uint32(body[5*i-5:])) {
+				return bad
+			}
+			g.sparse[idx] = rho
+		}
+		if n > g.sparseLimit() {
+			g.densify()
+		}
+	default:
+		return bad
+	}
+	*h = g
+	return nil
+}
@@ -0,0 +1,26 @@
+package probabilistic
+
+import (
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/concurrent"
+)
+
+func BenchmarkHyperLogLog_Add(b *testing.B) {
+	h := NewHyperLogLog[uint64](14, concurrent.Uint64Hasher)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Add(uint64(i))
+	}
+}
+
+func BenchmarkHyperLogLog_Estimate(b *testing.B) {
+	h := NewHyperLogLog[uint64](14, concurrent.Uint64Hasher)
+	for i := uint64(0); i < 1<<20; i++ {
+		h.Add(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = h.Estimate()
+	}
+}
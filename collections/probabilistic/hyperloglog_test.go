@@ -0,0 +1,170 @@
+package probabilistic_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections"
+	"github.com/khajamoddin/collections/collections/concurrent"
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+// checkEstimate fails if est is further from exact than allowed standard
+// errors for precision p.
+func checkEstimate(t *testing.T, p int, est uint64, exact int, sigmas float64) {
+	t.Helper()
+	tol := sigmas * 1.04 / math.Sqrt(float64(uint64(1)<<p)) * float64(exact)
+	if math.Abs(float64(est)-float64(exact)) > max(tol, 1) {
+		t.Fatalf("p=%d: estimate %d, exact %d", p, est, exact)
+	}
+}
+
+func TestHyperLogLogAccuracy(t *testing.T) {
+	for _, p := range []int{10, 14} {
+		h := probabilistic.NewHyperLogLog[string](p, concurrent.StringHasher)
+		exact := collections.NewSet[string]()
+		if h.Estimate() != 0 {
+			t.Fatalf("empty estimate %d", h.Estimate())
+		}
+		next := 1
+		for i := 0; i < 200000; i++ {
+			// Every item twice, so duplicates must not be counted.
+			u := "user:" + strconv.Itoa(i/2)
+			h.Add(u)
+			exact.Add(u)
+			if exact.Len() == next {
+				checkEstimate(t, p, h.Estimate(), exact.Len(), 4)
+				next *= 3
+			}
+		}
+		checkEstimate(t, p, h.Estimate(), exact.Len(), 4)
+	}
+}
+
+func TestHyperLogLogSmallCardinalitiesExact(t *testing.T) {
+	h := probabilistic.NewHyperLogLog[uint64](14, concurrent.Uint64Hasher)
+	for i := uint64(1); i <= 100; i++ {
+		h.Add(i)
+		if h.Estimate() != i {
+			t.Fatalf("estimate %d after %d items", h.Estimate(), i)
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	const p = 12
+	shards := make([]*probabilistic.HyperLogLog[string], 4)
+	exact := collections.NewSet[string]()
+	for s := range shards {
+		shards[s] = probabilistic.NewHyperLogLog[string](p, concurrent.StringHasher)
+		// Shards overlap: shard s sees users [s*10000, s*10000+30000).
+		n := 30000
+		if s == 0 {
+			n = 50 // stays sparse
+		}
+		for i := s * 10000; i < s*10000+n; i++ {
+			u := "user:" + strconv.Itoa(i)
+			shards[s].Add(u)
+			exact.Add(u)
+		}
+	}
+	total := probabilistic.NewHyperLogLog[string](p, concurrent.StringHasher)
+	for _, s := range shards {
+		if err := total.Merge(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkEstimate(t, p, total.Estimate(), exact.Len(), 4)
+
+	// Merging into a sparse sketch and merging a sketch into itself.
+	orig := shards[0].Estimate()
+	small := shards[0].Clone()
+	if err := small.Merge(shards[3]); err != nil {
+		t.Fatal(err)
+	}
+	before := small.Estimate()
+	small.Merge(small)
+	if small.Estimate() != before || shards[0].Estimate() != orig {
+		t.Fatalf("merge changed unrelated sketches")
+	}
+
+	other := probabilistic.NewHyperLogLog[string](p+1, concurrent.StringHasher)
+	if err := total.Merge(other); !errors.Is(err, probabilistic.ErrIncompatible) {
+		t.Fatalf("Merge of different precisions: %v", err)
+	}
+}
+
+func TestHyperLogLogBinary(t *testing.T) {
+	for _, n := range []int{0, 20, 5000} {
+		a := probabilistic.NewHyperLogLog[int](11, func(i int) uint64 { return uint64(i) })
+		b := probabilistic.NewHyperLogLog[int](11, func(i int) uint64 { return uint64(i) })
+		for i := 0; i < n; i++ {
+			a.Add(i)
+			b.Add(n - 1 - i)
+		}
+		da, err := a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, _ := b.MarshalBinary()
+		if !bytes.Equal(da, db) {
+			t.Fatalf("n=%d: encoding depends on insertion order", n)
+		}
+		c := probabilistic.NewHyperLogLog[int](4, func(i int) uint64 { return uint64(i) })
+		if err := c.UnmarshalBinary(da); err != nil {
+			t.Fatal(err)
+		}
+		if c.Precision() != 11 || c.Estimate() != a.Estimate() {
+			t.Fatalf("n=%d: round trip estimate %d, want %d", n, c.Estimate(), a.Estimate())
+		}
+		dc, _ := c.MarshalBinary()
+		if !bytes.Equal(da, dc) {
+			t.Fatalf("n=%d: re-encoding differs", n)
+		}
+	}
+
+	h := probabilistic.NewHyperLogLog[int](11, func(i int) uint64 { return uint64(i) })
+	for _, bad := range [][]byte{
+		nil,
+		[]byte("HLL1\x03\x00\x00\x00\x00\x00"),
+		[]byte("HLL1\x0b\x02"),
+		[]byte("HLL1\x0b\x01\x00"),
+		[]byte("HLL1\x0b\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00"),
+		[]byte("HLL1\x0b\x00\x02\x00\x00\x00\x05\x00\x00\x00\x01\x05\x00\x00\x00\x01"),
+	} {
+		if err := h.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("UnmarshalBinary(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestHyperLogLogClearAndNil(t *testing.T) {
+	h := probabilistic.NewHyperLogLog[string](8, concurrent.StringHasher)
+	for i := 0; i < 1000; i++ {
+		h.Add(strconv.Itoa(i))
+	}
+	h.Clear()
+	if h.Estimate() != 0 {
+		t.Fatalf("estimate %d after Clear", h.Estimate())
+	}
+
+	var n *probabilistic.HyperLogLog[string]
+	n.Add("a")
+	if n.Estimate() != 0 || n.Precision() != 0 || n.Clone() != nil || n.Merge(h) == nil {
+		t.Fatalf("nil sketch not empty")
+	}
+
+	for _, p := range []int{probabilistic.MinPrecision - 1, probabilistic.MaxPrecision + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("precision %d did not panic", p)
+				}
+			}()
+			probabilistic.NewHyperLogLog[string](p, concurrent.StringHasher)
+		}()
+	}
+}
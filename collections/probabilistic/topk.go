@@ -0,0 +1,328 @@
+package probabilistic
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	collections "github.com/khajamoddin/collections/collections"
+)
+
+// TopK tracks the most frequent items in a stream using the Space-Saving
+// algorithm.
+//
+// It monitors at most k items. When a new item arrives and all k counters
+// are taken, the item with the smallest count is replaced and the newcomer
+// inherits that count as its possible overestimate. Any item occurring more
+// than Total/k times is guaranteed to be monitored, and each reported count
+// exceeds the true count by at most the entry's Error. Monitoring a few
+// times more items than are needed improves the accuracy of the top ones.
+//
+// The smallest counter is found with a collections.PriorityQueue; counts
+// that have since grown leave stale queue entries, which are skipped and
+// periodically compacted, so Add is O(log k) amortized.
+//
+// A TopK must be created with NewTopK; methods on a nil *TopK behave like an
+// empty tracker.
+type TopK[T comparable] struct {
+	k      int
+	counts map[T]TopKEntry[T]
+	mins   *collections.PriorityQueue[topKCount[T]]
+	total  uint64
+}
+
+// TopKEntry is a monitored item with its estimated count. The true count is
+// between Count-Error and Count.
+type TopKEntry[T any] struct {
+	Item  T      `json:"item"`
+	Count uint64 `json:"count"`
+	Error uint64 `json:"error"`
+}
+
+// topKCount is a queued count. It is stale once its item is no longer
+// monitored or its count has grown.
+type topKCount[T any] struct {
+	item  T
+	count uint64
+}
+
+// NewTopK creates a tracker that monitors up to k items. k must be at least
+// 1.
+func NewTopK[T comparable](k int) *TopK[T] {
+	if k < 1 {
+		panic(fmt.Sprintf("probabilistic: TopK capacity %d must be at least 1", k))
+	}
+	return &TopK[T]{
+		k:      k,
+		counts: make(map[T]TopKEntry[T], k),
+		mins:   collections.NewPriorityQueue(func(a, b topKCount[T]) bool { return a.count < b.count }),
+	}
+}
+
+// Add records one occurrence of v.
+func (t *TopK[T]) Add(v T) {
+	t.AddCount(v, 1)
+}
+
+// AddCount records n occurrences of v.
+func (t *TopK[T]) AddCount(v T, n uint64) {
+	if t == nil || n == 0 {
+		return
+	}
+	t.total += n
+	e, ok := t.counts[v]
+	switch {
+	case ok:
+		e.Count += n
+	case len(t.counts) < t.k:
+		e = TopKEntry[T]{Item: v, Count: n}
+	default:
+		evicted := t.popMin()
+		delete(t.counts, evicted.Item)
+		e = TopKEntry[T]{Item: v, Count: evicted.Count + n, Error: evicted.Count}
+	}
+	t.counts[v] = e
+	t.mins.Push(topKCount[T]{e.Item, e.Count})
+	if t.mins.Len() > 2*len(t.counts)+16 {
+		t.requeue()
+	}
+}
+
+// live reports whether a queued count is current.
+func (t *TopK[T]) live(c topKCount[T]) bool {
+	e, ok := t.counts[c.item]
+	return ok && e.Count == c.count
+}
+
+// peekMin returns the monitored entry with the smallest count, discarding
+// stale queue entries on the way.
+func (t *TopK[T]) peekMin() (TopKEntry[T], bool) {
+	for {
+		c, ok := t.mins.Peek()
+		if !ok {
+			return TopKEntry[T]{}, false
+		}
+		if t.live(c) {
+			return t.counts[c.item], true
+		}
+		t.mins.Pop()
+	}
+}
+
+func (t *TopK[T]) popMin() TopKEntry[T] {
+	e, _ := t.peekMin()
+	t.mins.Pop()
+	return e
+}
+
+// requeue rebuilds the queue from the monitored counts, dropping stale
+// entries.
+func (t *TopK[T]) requeue() {
+	t.mins.Clear()
+	for _, e := range t.counts {
+		t.mins.Push(topKCount[T]{e.Item, e.Count})
+	}
+}
+
+// Count returns v's estimated count and the most it may be overestimated
+// by. ok is false if v is not monitored; its true count is then at most the
+// smallest monitored count, or zero if fewer than k items are monitored.
+func (t *TopK[T]) Count(v T) (count, overestimate uint64, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	e, ok := t.counts[v]
+	return e.Count, e.Error, ok
+}
+
+// Top returns the monitored items from most to least frequent. Items with
+// equal counts are ordered by smaller Error first, and otherwise in
+// unspecified order.
+func (t *TopK[T]) Top() []TopKEntry[T] {
+	if t == nil || len(t.counts) == 0 {
+		return nil
+	}
+	out := make([]TopKEntry[T], 0, len(t.counts))
+	for _, e := range t.counts {
+		out = append(out, e)
+	}
+	sortEntries(out)
+	return out
+}
+
+func sortEntries[T any](s []TopKEntry[T]) {
+	slices.SortFunc(s, func(a, b TopKEntry[T]) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Error, b.Error)
+	})
+}
+
+// Len returns the number of monitored items.
+func (t *TopK[T]) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.counts)
+}
+
+// Capacity returns the maximum number of monitored items.
+func (t *TopK[T]) Capacity() int {
+	if t == nil {
+		return 0
+	}
+	return t.k
+}
+
+// Total returns the number of occurrences added across all items.
+func (t *TopK[T]) Total() uint64 {
+	if t == nil {
+		return 0
+	}
+	return t.total
+}
+
+// Clear removes all items.
+func (t *TopK[T]) Clear() {
+	if t == nil {
+		return
+	}
+	clear(t.counts)
+	t.mins.Clear()
+	t.total = 0
+}
+
+// floor is the count an unmonitored item may have had: the smallest
+// monitored count once all k counters are taken, and zero before.
+func (t *TopK[T]) floor() uint64 {
+	if len(t.counts) < t.k {
+		return 0
+	}
+	e, _ := t.peekMin()
+	return e.Count
+}
+
+// Merge combines other into t, so that t summarizes both streams. An item
+// missing from one summary is credited with that summary's smallest count,
+// which keeps counts from being underestimated; the k largest combined
+// counts are kept. other may have a different capacity.
+func (t *TopK[T]) Merge(other *TopK[T]) error {
+	if t == nil || other == nil {
+		return errors.New("probabilistic: Merge with nil *TopK")
+	}
+	ft, fo := t.floor(), other.floor()
+	merged := make([]TopKEntry[T], 0, len(t.counts)+len(other.counts))
+	for v, e := range t.counts {
+		if o, ok := other.counts[v]; ok {
+			e.Count, e.Error = e.Count+o.Count, e.Error+o.Error
+		} else {
+			e.Count, e.Error = e.Count+fo, e.Error+fo
+		}
+		merged = append(merged, e)
+	}
+	for v, o := range other.counts {
+		if _, ok := t.counts[v]; !ok {
+			merged = append(merged, TopKEntry[T]{Item: v, Count: o.Count + ft, Error: o.Error + ft})
+		}
+	}
+	sortEntries(merged)
+	t.replace(merged, t.total+other.total)
+	return nil
+}
+
+// replace sets the monitored entries to the first k of entries.
+func (t *TopK[T]) replace(entries []TopKEntry[T], total uint64) {
+	t.counts = make(map[T]TopKEntry[T], t.k)
+	for _, e := range entries[:min(len(entries), t.k)] {
+		t.counts[e.Item] = e
+	}
+	t.total = total
+	t.requeue()
+}
+
+// topKJSON is the serialized form of a TopK.
+type topKJSON[T any] struct {
+	Capacity int            `json:"capacity"`
+	Total    uint64         `json:"total"`
+	Items    []TopKEntry[T] `json:"items"`
+}
+
+func (t *TopK[T]) snapshot() topKJSON[T] {
+	items := t.Top()
+	if items == nil {
+		items = []TopKEntry[T]{}
+	}
+	return topKJSON[T]{Capacity: t.k, Total: t.total, Items: items}
+}
+
+func (t *TopK[T]) restore(s topKJSON[T]) error {
+	if s.Capacity < 1 || len(s.Items) > s.Capacity {
+		return errors.New("probabilistic: malformed TopK data")
+	}
+	for _, e := range s.Items {
+		if e.Error > e.Count {
+			return errors.New("probabilistic: malformed TopK data")
+		}
+	}
+	if t.mins == nil {
+		*t = *NewTopK[T](s.Capacity)
+	}
+	t.k = s.Capacity
+	t.replace(s.Items, s.Total)
+	return nil
+}
+
+// MarshalJSON encodes the tracker as an object with its capacity, total and
+// monitored items from most to least frequent. A nil tracker encodes as
+// null.
+func (t *TopK[T]) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.snapshot())
+}
+
+// UnmarshalJSON replaces the tracker with one encoded by MarshalJSON. A JSON
+// null leaves the tracker unchanged.
+func (t *TopK[T]) UnmarshalJSON(data []byte) error {
+	if t == nil {
+		return errors.New("probabilistic: UnmarshalJSON on nil *TopK")
+	}
+	var s *topKJSON[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	return t.restore(*s)
+}
+
+// GobEncode encodes the tracker with encoding/gob.
+func (t *TopK[T]) GobEncode() ([]byte, error) {
+	if t == nil {
+		return nil, errors.New("probabilistic: GobEncode on nil *TopK")
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t.snapshot()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the tracker with one encoded by GobEncode.
+func (t *TopK[T]) GobDecode(data []byte) error {
+	if t == nil {
+		return errors.New("probabilistic: GobDecode on nil *TopK")
+	}
+	var s topKJSON[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	return t.restore(s)
+}
@@ -0,0 +1,20 @@
+package probabilistic
+
+import (
+	"strconv"
+	"testing"
+)
+
+func BenchmarkTopK_Add(b *testing.B) {
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	tk := NewTopK[string](100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Skewed stream: low-numbered keys repeat far more often.
+		j := i % len(keys)
+		tk.Add(keys[j*j/len(keys)])
+	}
+}
@@ -0,0 +1,166 @@
+package probabilistic_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/khajamoddin/collections/collections/probabilistic"
+)
+
+// checkTopK verifies that every reported count bounds the exact count.
+func checkTopK(t *testing.T, tk *probabilistic.TopK[string], exact map[string]uint64) {
+	t.Helper()
+	for _, e := range tk.Top() {
+		c := exact[e.Item]
+		if e.Count < c || e.Count-e.Error > c {
+			t.Fatalf("%s: count %d error %d, exact %d", e.Item, e.Count, e.Error, c)
+		}
+	}
+}
+
+func TestTopKHeavyHitters(t *testing.T) {
+	keys, exact := zipfStream(100000)
+	tk := probabilistic.NewTopK[string](50)
+	for _, k := range keys {
+		tk.Add(k)
+	}
+	if tk.Len() != 50 || tk.Capacity() != 50 || tk.Total() != uint64(len(keys)) {
+		t.Fatalf("Len %d Total %d", tk.Len(), tk.Total())
+	}
+	checkTopK(t, tk, exact)
+	top := tk.Top()
+	for i := range top {
+		if want := "key:" + strconv.Itoa(i+1); i < 4 && top[i].Item != want {
+			t.Fatalf("top[%d] = %s, want %s", i, top[i].Item, want)
+		}
+		if i > 0 && top[i].Count > top[i-1].Count {
+			t.Fatalf("Top not sorted at %d", i)
+		}
+	}
+	// Every item above Total/k must be monitored.
+	for k, c := range exact {
+		if c > tk.Total()/50 {
+			if _, _, ok := tk.Count(k); !ok {
+				t.Fatalf("heavy hitter %s (%d) not monitored", k, c)
+			}
+		}
+	}
+}
+
+func TestTopKEviction(t *testing.T) {
+	tk := probabilistic.NewTopK[string](2)
+	tk.AddCount("a", 5)
+	tk.AddCount("b", 3)
+	tk.Add("c")
+	if _, _, ok := tk.Count("b"); ok {
+		t.Fatalf("smallest item not evicted")
+	}
+	if c, e, ok := tk.Count("c"); !ok || c != 4 || e != 3 {
+		t.Fatalf("Count(c) = %d, %d, %v", c, e, ok)
+	}
+	tk.AddCount("d", 0)
+	if tk.Len() != 2 || tk.Total() != 9 {
+		t.Fatalf("Len %d Total %d", tk.Len(), tk.Total())
+	}
+	// Many increments leave stale queue entries that must not confuse
+	// eviction.
+	for i := 0; i < 1000; i++ {
+		tk.Add("a")
+	}
+	tk.Add("e")
+	if _, _, ok := tk.Count("a"); !ok {
+		t.Fatalf("most frequent item evicted")
+	}
+	tk.Clear()
+	if tk.Len() != 0 || tk.Total() != 0 || tk.Top() != nil {
+		t.Fatalf("Clear left items")
+	}
+}
+
+func TestTopKMerge(t *testing.T) {
+	keys, exact := zipfStream(20000)
+	a, b := probabilistic.NewTopK[string](30), probabilistic.NewTopK[string](30)
+	for i, k := range keys {
+		if i%3 == 0 {
+			a.Add(k)
+		} else {
+			b.Add(k)
+		}
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Total() != uint64(len(keys)) || a.Len() != 30 {
+		t.Fatalf("Total %d Len %d", a.Total(), a.Len())
+	}
+	checkTopK(t, a, exact)
+	if a.Top()[0].Item != "key:1" {
+		t.Fatalf("top item %s", a.Top()[0].Item)
+	}
+
+	// Merging into an empty tracker copies the other.
+	c := probabilistic.NewTopK[string](30)
+	c.Merge(b)
+	if c.Len() != b.Len() || c.Total() != b.Total() {
+		t.Fatalf("merge into empty: Len %d Total %d", c.Len(), c.Total())
+	}
+	if c.Merge(nil) == nil {
+		t.Fatalf("Merge(nil) succeeded")
+	}
+}
+
+func TestTopKSerialization(t *testing.T) {
+	keys, _ := zipfStream(5000)
+	tk := probabilistic.NewTopK[string](10)
+	for _, k := range keys {
+		tk.Add(k)
+	}
+	data, err := json.Marshal(tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got probabilistic.TopK[string]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Capacity() != 10 || got.Total() != tk.Total() || len(got.Top()) != 10 {
+		t.Fatalf("JSON round trip: %s", data)
+	}
+	for i, e := range tk.Top() {
+		if g := got.Top()[i]; g.Count != e.Count || g.Error != e.Error {
+			t.Fatalf("JSON round trip entry %d: %+v, want %+v", i, g, e)
+		}
+	}
+	got.Add("new")
+	if got.Len() != 10 {
+		t.Fatalf("decoded tracker does not evict")
+	}
+
+	gobData, err := tk.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := probabilistic.NewTopK[string](1)
+	if err := g.GobDecode(gobData); err != nil {
+		t.Fatal(err)
+	}
+	if g.Capacity() != 10 || g.Total() != tk.Total() || g.Top()[0] != tk.Top()[0] {
+		t.Fatalf("gob round trip")
+	}
+
+	for _, bad := range []string{`{"capacity":0}`, `{"capacity":1,"items":[{"item":"a","count":1},{"item":"b","count":1}]}`, `{"capacity":1,"items":[{"item":"a","count":1,"error":2}]}`, `[]`} {
+		if err := json.Unmarshal([]byte(bad), g); err == nil {
+			t.Fatalf("UnmarshalJSON(%s) succeeded", bad)
+		}
+	}
+	if err := json.Unmarshal([]byte("null"), g); err != nil || g.Capacity() != 10 {
+		t.Fatalf("null changed tracker")
+	}
+
+	var n *probabilistic.TopK[string]
+	n.Add("a")
+	if b, _ := json.Marshal(n); string(b) != "null" || n.Len() != 0 || n.Top() != nil {
+		t.Fatalf("nil tracker not empty")
+	}
+}
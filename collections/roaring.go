@@ -0,0 +1,1043 @@
+package collections
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// RoaringBitmap is a compressed set of uint32 values for large, sparse or
+// clustered integer sets.
+//
+// Values are split by their high 16 bits into chunks of 65536. Each non-empty
+// chunk is stored in whichever container suits it: a sorted array of low
+// bits for up to 4096 values, a 65536-bit bitmap for more, or a list of runs
+// for long consecutive ranges. Add, Remove and Has are O(log c) for c chunks
+// plus the container cost; set algebra works chunk by chunk, using merges
+// for arrays and word-parallel operations for bitmaps.
+//
+// Runs are created by AddRange for whole chunks and by RunOptimize; other
+// updates keep or convert to array and bitmap containers. The zero value is
+// an empty bitmap ready to use.
+type RoaringBitmap struct {
+	keys       []uint16 // sorted high 16 bits of each chunk
+	containers []*roaringContainer
+}
+
+const (
+	roaringArrayMax    = 4096 // array containers hold at most this many values
+	roaringBitmapWords = 1 << 16 / 64
+)
+
+type roaringKind uint8
+
+const (
+	roaringArray roaringKind = iota
+	roaringBitmap
+	roaringRun
+)
+
+// roaringContainer holds the low 16 bits of the values in one chunk. Only
+// the field for its kind is used. A container is never empty; array
+// containers hold at most roaringArrayMax values and bitmap containers more.
+type roaringContainer struct {
+	kind  roaringKind
+	n     int      // cardinality
+	array []uint16 // sorted
+	bits  []uint64 // roaringBitmapWords words
+	runs  []roaringInterval
+}
+
+// roaringInterval is the inclusive range [start, last].
+type roaringInterval struct {
+	start, last uint16
+}
+
+// NewRoaringBitmap creates a new empty RoaringBitmap.
+func NewRoaringBitmap() *RoaringBitmap {
+	return &RoaringBitmap{}
+}
+
+// NewRoaringBitmapFromSlice creates a bitmap containing the values of the
+// slice.
+func NewRoaringBitmapFromSlice(s []uint32) *RoaringBitmap {
+	r := NewRoaringBitmap()
+	for _, v := range s {
+		r.Add(v)
+	}
+	return r
+}
+
+// Add inserts v.
+func (r *RoaringBitmap) Add(v uint32) {
+	if r == nil {
+		return
+	}
+	c := r.chunk(uint16(v >> 16))
+	c.add(uint16(v))
+}
+
+// Remove deletes v if present.
+func (r *RoaringBitmap) Remove(v uint32) {
+	if r == nil {
+		return
+	}
+	i, ok := slices.BinarySearch(r.keys, uint16(v>>16))
+	if !ok {
+		return
+	}
+	c := r.containers[i]
+	c.remove(uint16(v))
+	if c.n == 0 {
+		r.deleteChunk(i)
+	}
+}
+
+// Has reports whether v is in the bitmap.
+func (r *RoaringBitmap) Has(v uint32) bool {
+	if r == nil {
+		return false
+	}
+	i, ok := slices.BinarySearch(r.keys, uint16(v>>16))
+	return ok && r.containers[i].has(uint16(v))
+}
+
+// Len returns the number of values. Complexity: O(c) for c chunks.
+func (r *RoaringBitmap) Len() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range r.containers {
+		n += c.n
+	}
+	return n
+}
+
+// Clear removes all values.
+func (r *RoaringBitmap) Clear() {
+	if r == nil {
+		return
+	}
+	r.keys, r.containers = nil, nil
+}
+
+// AddRange inserts every value in [lo, hi). hi may be 1<<32 to include
+// math.MaxUint32; an empty or inverted range is ignored.
+func (r *RoaringBitmap) AddRange(lo, hi uint64) {
+	if r == nil {
+		return
+	}
+	hi = min(hi, 1<<32)
+	for lo < hi {
+		key := uint16(lo >> 16)
+		end := min(hi, (lo>>16+1)<<16)
+		first, last := uint16(lo), uint16(end-1)
+		c := r.chunk(key)
+		if first == 0 && last == 0xFFFF {
+			*c = roaringContainer{kind: roaringRun, n: 1 << 16, runs: []roaringInterval{{0, 0xFFFF}}}
+		} else {
+			c.toBitmap()
+			setBits(c.bits, first, last)
+			c.n = popcount(c.bits)
+			c.normalize()
+		}
+		lo = end
+	}
+}
+
+// RemoveRange deletes every value in [lo, hi). hi may be 1<<32 to include
+// math.MaxUint32; an empty or inverted range is ignored.
+func (r *RoaringBitmap) RemoveRange(lo, hi uint64) {
+	if r == nil {
+		return
+	}
+	hi = min(hi, 1<<32)
+	for lo < hi {
+		key := uint16(lo >> 16)
+		end := min(hi, (lo>>16+1)<<16)
+		first, last := uint16(lo), uint16(end-1)
+		lo = end
+		i, ok := slices.BinarySearch(r.keys, key)
+		if !ok {
+			continue
+		}
+		if first == 0 && last == 0xFFFF {
+			r.deleteChunk(i)
+			continue
+		}
+		c := r.containers[i]
+		c.toBitmap()
+		clearBits(c.bits, first, last)
+		if c.n = popcount(c.bits); c.n == 0 {
+			r.deleteChunk(i)
+			continue
+		}
+		c.normalize()
+	}
+}
+
+// Min returns the smallest value.
+func (r *RoaringBitmap) Min() (uint32, bool) {
+	if r == nil || len(r.keys) == 0 {
+		return 0, false
+	}
+	for v := range r.containers[0].all() {
+		return uint32(r.keys[0])<<16 | uint32(v), true
+	}
+	return 0, false
+}
+
+// Max returns the largest value.
+func (r *RoaringBitmap) Max() (uint32, bool) {
+	if r == nil || len(r.keys) == 0 {
+		return 0, false
+	}
+	i := len(r.keys) - 1
+	return uint32(r.keys[i])<<16 | uint32(r.containers[i].max()), true
+}
+
+// All returns an iterator over the values in ascending order.
+func (r *RoaringBitmap) All() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		if r == nil {
+			return
+		}
+		for i, c := range r.containers {
+			high := uint32(r.keys[i]) << 16
+			for v := range c.all() {
+				if !yield(high | uint32(v)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Values returns the values in ascending order.
+func (r *RoaringBitmap) Values() []uint32 {
+	if r.Len() == 0 {
+		return nil
+	}
+	out := make([]uint32, 0, r.Len())
+	for v := range r.All() {
+		out = append(out, v)
+	}
+	return out
+}
+
+// ToSlice returns the values in ascending order.
+func (r *RoaringBitmap) ToSlice() []uint32 {
+	return r.Values()
+}
+
+// Clone returns a deep copy of the bitmap.
+func (r *RoaringBitmap) Clone() *RoaringBitmap {
+	out := NewRoaringBitmap()
+	if r == nil {
+		return out
+	}
+	out.keys = slices.Clone(r.keys)
+	out.containers = make([]*roaringContainer, len(r.containers))
+	for i, c := range r.containers {
+		out.containers[i] = c.clone()
+	}
+	return out
+}
+
+// Equal reports whether r and other contain the same values.
+func (r *RoaringBitmap) Equal(other *RoaringBitmap) bool {
+	if r.Len() != other.Len() {
+		return false
+	}
+	if r == nil || other == nil {
+		return true
+	}
+	if !slices.Equal(r.keys, other.keys) {
+		return false
+	}
+	for i, c := range r.containers {
+		if !c.equal(other.containers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RoaringBitmap) Union(other *RoaringBitmap) *RoaringBitmap {
+	return r.merge(other, true, true, (*roaringContainer).or)
+}
+
+func (r *RoaringBitmap) Intersection(other *RoaringBitmap) *RoaringBitmap {
+	return r.merge(other, false, false, (*roaringContainer).and)
+}
+
+func (r *RoaringBitmap) Difference(other *RoaringBitmap) *RoaringBitmap {
+	return r.merge(other, true, false, (*roaringContainer).andNot)
+}
+
+func (r *RoaringBitmap) SymmetricDifference(other *RoaringBitmap) *RoaringBitmap {
+	return r.merge(other, true, true, (*roaringContainer).xor)
+}
+
+// IsSubset reports whether every value of r is in other. It compares chunk
+// by chunk and stops at the first value missing from other.
+func (r *RoaringBitmap) IsSubset(other *RoaringBitmap) bool {
+	if r.Len() > other.Len() {
+		return false
+	}
+	if r == nil {
+		return true
+	}
+	j := 0
+	for i, key := range r.keys {
+		for j < len(other.keys) && other.keys[j] < key {
+			j++
+		}
+		if j == len(other.keys) || other.keys[j] != key || !r.containers[i].subsetOf(other.containers[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDisjoint reports whether r and other have no values in common. It
+// compares the chunks both hold and stops at the first shared value.
+func (r *RoaringBitmap) IsDisjoint(other *RoaringBitmap) bool {
+	if r == nil || other == nil {
+		return true
+	}
+	i, j := 0, 0
+	for i < len(r.keys) && j < len(other.keys) {
+		switch a, b := r.keys[i], other.keys[j]; {
+		case a < b:
+			i++
+		case b < a:
+			j++
+		default:
+			if r.containers[i].intersects(other.containers[j]) {
+				return false
+			}
+			i++
+			j++
+		}
+	}
+	return true
+}
+
+// RunOptimize converts each container to run encoding where that is
+// smaller, and back to array or bitmap encoding where it is not. It is worth
+// calling after building a bitmap with long consecutive ranges and before
+// serializing it.
+func (r *RoaringBitmap) RunOptimize() {
+	if r == nil {
+		return
+	}
+	for _, c := range r.containers {
+		c.runOptimize()
+	}
+}
+
+// merge combines r and other chunk by chunk. Chunks present on only one
+// side are copied when keepLeft or keepRight allows; chunks present on both
+// are combined by op, which returns nil for an empty result.
+func (r *RoaringBitmap) merge(other *RoaringBitmap, keepLeft, keepRight bool, op func(a, b *roaringContainer) *roaringContainer) *RoaringBitmap {
+	out := NewRoaringBitmap()
+	var ak, bk []uint16
+	var ac, bc []*roaringContainer
+	if r != nil {
+		ak, ac = r.keys, r.containers
+	}
+	if other != nil {
+		bk, bc = other.keys, other.containers
+	}
+	i, j := 0, 0
+	for i < len(ak) || j < len(bk) {
+		switch {
+		case j == len(bk) || (i < len(ak) && ak[i] < bk[j]):
+			if keepLeft {
+				out.appendChunk(ak[i], ac[i].clone())
+			}
+			i++
+		case i == len(ak) || bk[j] < ak[i]:
+			if keepRight {
+				out.appendChunk(bk[j], bc[j].clone())
+			}
+			j++
+		default:
+			if c := op(ac[i], bc[j]); c != nil {
+				out.appendChunk(ak[i], c)
+			}
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func (r *RoaringBitmap) appendChunk(key uint16, c *roaringContainer) {
+	r.keys = append(r.keys, key)
+	r.containers = append(r.containers, c)
+}
+
+// chunk returns the container for key, inserting an empty array container
+// for the caller to fill if there is none.
+func (r *RoaringBitmap) chunk(key uint16) *roaringContainer {
+	i, ok := slices.BinarySearch(r.keys, key)
+	if ok {
+		return r.containers[i]
+	}
+	c := &roaringContainer{}
+	r.keys = slices.Insert(r.keys, i, key)
+	r.containers = slices.Insert(r.containers, i, c)
+	return c
+}
+
+func (r *RoaringBitmap) deleteChunk(i int) {
+	r.keys = slices.Delete(r.keys, i, i+1)
+	r.containers = slices.Delete(r.containers, i, i+1)
+}
+
+func (c *roaringContainer) has(v uint16) bool {
+	switch c.kind {
+	case roaringArray:
+		_, ok := slices.BinarySearch(c.array, v)
+		return ok
+	case roaringBitmap:
+		return c.bits[v/64]&(1<<(v%64)) != 0
+	}
+	i := c.runIndex(v)
+	return i >= 0
+}
+
+// runIndex returns the index of the run containing v, or -1.
+func (c *roaringContainer) runIndex(v uint16) int {
+	i, _ := slices.BinarySearchFunc(c.runs, v, func(r roaringInterval, v uint16) int {
+		if r.last < v {
+			return -1
+		}
+		if r.start > v {
+			return 1
+		}
+		return 0
+	})
+	if i < len(c.runs) && c.runs[i].start <= v && v <= c.runs[i].last {
+		return i
+	}
+	return -1
+}
+
+func (c *roaringContainer) add(v uint16) {
+	if c.kind == roaringRun {
+		if c.runIndex(v) >= 0 {
+			return
+		}
+		c.fromRuns()
+	}
+	switch c.kind {
+	case roaringArray:
+		i, ok := slices.BinarySearch(c.array, v)
+		if ok {
+			return
+		}
+		c.array = slices.Insert(c.array, i, v)
+		c.n++
+		c.normalize()
+	case roaringBitmap:
+		if w, m := v/64, uint64(1)<<(v%64); c.bits[w]&m == 0 {
+			c.bits[w] |= m
+			c.n++
+			c.normalize()
+		}
+	}
+}
+
+func (c *roaringContainer) remove(v uint16) {
+	if c.kind == roaringRun {
+		if c.runIndex(v) < 0 {
+			return
+		}
+		// Normalize only after the removal: a full chunk would be turned
+		// straight back into a run.
+		c.toBitmap()
+	}
+	switch c.kind {
+	case roaringArray:
+		if i, ok := slices.BinarySearch(c.array, v); ok {
+			c.array = slices.Delete(c.array, i, i+1)
+			c.n--
+		}
+	case roaringBitmap:
+		if w, m := v/64, uint64(1)<<(v%64); c.bits[w]&m != 0 {
+			c.bits[w] &^= m
+			c.n--
+			c.normalize()
+		}
+	}
+}
+
+func (c *roaringContainer) max() uint16 {
+	switch c.kind {
+	case roaringArray:
+		return c.array[len(c.array)-1]
+	case roaringBitmap:
+		for w := len(c.bits) - 1; ; w-- {
+			if c.bits[w] != 0 {
+				return uint16(w*64 + 63 - bits.LeadingZeros64(c.bits[w]))
+			}
+		}
+	}
+	return c.runs[len(c.runs)-1].last
+}
+
+// all iterates over the low bits in ascending order.
+func (c *roaringContainer) all() iter.Seq[uint16] {
+	return func(yield func(uint16) bool) {
+		switch c.kind {
+		case roaringArray:
+			for _, v := range c.array {
+				if !yield(v) {
+					return
+				}
+			}
+		case roaringBitmap:
+			for w, word := range c.bits {
+				for ; word != 0; word &= word - 1 {
+					if !yield(uint16(w*64 + bits.TrailingZeros64(word))) {
+						return
+					}
+				}
+			}
+		case roaringRun:
+			for _, run := range c.runs {
+				for v := uint32(run.start); v <= uint32(run.last); v++ {
+					if !yield(uint16(v)) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *roaringContainer) clone() *roaringContainer {
+	return &roaringContainer{
+		kind:  c.kind,
+		n:     c.n,
+		array: slices.Clone(c.array),
+		bits:  slices.Clone(c.bits),
+		runs:  slices.Clone(c.runs),
+	}
+}
+
+func (c *roaringContainer) equal(o *roaringContainer) bool {
+	if c.n != o.n {
+		return false
+	}
+	if c.kind == o.kind && c.kind == roaringArray {
+		return slices.Equal(c.array, o.array)
+	}
+	return slices.Equal(c.bitmap(), o.bitmap())
+}
+
+// bitmap returns the container's values as bitmap words. The result aliases
+// the container's own words for bitmap containers and must not be modified.
+func (c *roaringContainer) bitmap() []uint64 {
+	if c.kind == roaringBitmap {
+		return c.bits
+	}
+	words := make([]uint64, roaringBitmapWords)
+	switch c.kind {
+	case roaringArray:
+		for _, v := range c.array {
+			words[v/64] |= 1 << (v % 64)
+		}
+	case roaringRun:
+		for _, run := range c.runs {
+			setBits(words, run.start, run.last)
+		}
+	}
+	return words
+}
+
+// toBitmap converts the container to bitmap encoding in place.
+func (c *roaringContainer) toBitmap() {
+	if c.kind == roaringBitmap {
+		return
+	}
+	c.bits = c.bitmap()
+	c.kind, c.array, c.runs = roaringBitmap, nil, nil
+}
+
+// fromRuns converts a run container to array or bitmap encoding.
+func (c *roaringContainer) fromRuns() {
+	c.toBitmap()
+	c.normalize()
+}
+
+// normalize restores the array/bitmap size invariant after c.n changed, and
+// turns a full chunk into a single run.
+func (c *roaringContainer) normalize() {
+	switch {
+	case c.n == 1<<16 && c.kind != roaringRun:
+		*c = roaringContainer{kind: roaringRun, n: 1 << 16, runs: []roaringInterval{{0, 0xFFFF}}}
+	case c.kind == roaringBitmap && c.n <= roaringArrayMax:
+		array := make([]uint16, 0, c.n)
+		for v := range c.all() {
+			array = append(array, v)
+		}
+		*c = roaringContainer{kind: roaringArray, n: c.n, array: array}
+	case c.kind == roaringArray && c.n > roaringArrayMax:
+		c.toBitmap()
+	}
+}
+
+// runOptimize picks the smallest of the three encodings.
+func (c *roaringContainer) runOptimize() {
+	var runs []roaringInterval
+	for v := range c.all() {
+		if k := len(runs) - 1; k >= 0 && uint32(runs[k].last)+1 == uint32(v) {
+			runs[k].last = v
+		} else {
+			runs = append(runs, roaringInterval{v, v})
+		}
+	}
+	runBytes := 2 + 4*len(runs)
+	otherBytes := 8 * roaringBitmapWords
+	if c.n <= roaringArrayMax {
+		otherBytes = 2 * c.n
+	}
+	switch {
+	case runBytes < otherBytes:
+		*c = roaringContainer{kind: roaringRun, n: c.n, runs: runs}
+	case c.kind == roaringRun:
+		c.fromRuns()
+	}
+}
+
+// fromBitmap builds a container from bitmap words it takes ownership of, or
+// returns nil if they are empty.
+func fromBitmap(words []uint64) *roaringContainer {
+	n := popcount(words)
+	if n == 0 {
+		return nil
+	}
+	c := &roaringContainer{kind: roaringBitmap, n: n, bits: words}
+	c.normalize()
+	return c
+}
+
+func fromArray(array []uint16) *roaringContainer {
+	if len(array) == 0 {
+		return nil
+	}
+	c := &roaringContainer{kind: roaringArray, n: len(array), array: array}
+	c.normalize()
+	return c
+}
+
+func (c *roaringContainer) and(o *roaringContainer) *roaringContainer {
+	if c.kind == roaringArray && o.kind == roaringArray {
+		var out []uint16
+		i, j := 0, 0
+		for i < len(c.array) && j < len(o.array) {
+			switch a, b := c.array[i], o.array[j]; {
+			case a < b:
+				i++
+			case b < a:
+				j++
+			default:
+				out = append(out, a)
+				i++
+				j++
+			}
+		}
+		return fromArray(out)
+	}
+	if c.kind != roaringArray && o.kind == roaringArray {
+		c, o = o, c
+	}
+	if c.kind == roaringArray {
+		var out []uint16
+		for _, v := range c.array {
+			if o.has(v) {
+				out = append(out, v)
+			}
+		}
+		return fromArray(out)
+	}
+	a, b := c.bitmap(), o.bitmap()
+	words := make([]uint64, roaringBitmapWords)
+	for i := range words {
+		words[i] = a[i] & b[i]
+	}
+	return fromBitmap(words)
+}
+
+// subsetOf reports whether every value of c is in o.
+func (c *roaringContainer) subsetOf(o *roaringContainer) bool {
+	if c.n > o.n {
+		return false
+	}
+	switch {
+	case c.kind == roaringArray:
+		for _, v := range c.array {
+			if !o.has(v) {
+				return false
+			}
+		}
+		return true
+	case c.kind == roaringRun && o.kind == roaringRun:
+		for _, run := range c.runs {
+			k := o.runIndex(run.start)
+			if k < 0 || o.runs[k].last < run.last {
+				return false
+			}
+		}
+		return true
+	}
+	a, b := c.bitmap(), o.bitmap()
+	for i := range a {
+		if a[i]&^b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// intersects reports whether c and o share a value.
+func (c *roaringContainer) intersects(o *roaringContainer) bool {
+	if c.kind != roaringArray && o.kind == roaringArray {
+		c, o = o, c
+	}
+	switch {
+	case c.kind == roaringArray && o.kind == roaringArray:
+		i, j := 0, 0
+		for i < len(c.array) && j < len(o.array) {
+			switch a, b := c.array[i], o.array[j]; {
+			case a < b:
+				i++
+			case b < a:
+				j++
+			default:
+				return true
+			}
+		}
+		return false
+	case c.kind == roaringArray:
+		for _, v := range c.array {
+			if o.has(v) {
+				return true
+			}
+		}
+		return false
+	case c.kind == roaringRun && o.kind == roaringRun:
+		i, j := 0, 0
+		for i < len(c.runs) && j < len(o.runs) {
+			switch a, b := c.runs[i], o.runs[j]; {
+			case a.last < b.start:
+				i++
+			case b.last < a.start:
+				j++
+			default:
+				return true
+			}
+		}
+		return false
+	}
+	a, b := c.bitmap(), o.bitmap()
+	for i := range a {
+		if a[i]&b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *roaringContainer) andNot(o *roaringContainer) *roaringContainer {
+	if c.kind == roaringArray {
+		var out []uint16
+		for _, v := range c.array {
+			if !o.has(v) {
+				out = append(out, v)
+			}
+		}
+		return fromArray(out)
+	}
+	a, b := c.bitmap(), o.bitmap()
+	words := make([]uint64, roaringBitmapWords)
+	for i := range words {
+		words[i] = a[i] &^ b[i]
+	}
+	return fromBitmap(words)
+}
+
+func (c *roaringContainer) or(o *roaringContainer) *roaringContainer {
+	if c.kind == roaringArray && o.kind == roaringArray {
+		out := make([]uint16, 0, len(c.array)+len(o.array))
+		i, j := 0, 0
+		for i < len(c.array) && j < len(o.array) {
+			switch a, b := c.array[i], o.array[j]; {
+			case a < b:
+				out = append(out, a)
+				i++
+			case b < a:
+				out = append(out, b)
+				j++
+			default:
+				out = append(out, a)
+				i++
+				j++
+			}
+		}
+		out = append(out, c.array[i:]...)
+		return fromArray(append(out, o.array[j:]...))
+	}
+	a, b := c.bitmap(), o.bitmap()
+	words := make([]uint64, roaringBitmapWords)
+	for i := range words {
+		words[i] = a[i] | b[i]
+	}
+	return fromBitmap(words)
+}
+
+func (c *roaringContainer) xor(o *roaringContainer) *roaringContainer {
+	if c.kind == roaringArray && o.kind == roaringArray {
+		var out []uint16
+		i, j := 0, 0
+		for i < len(c.array) && j < len(o.array) {
+			switch a, b := c.array[i], o.array[j]; {
+			case a < b:
+				out = append(out, a)
+				i++
+			case b < a:
+				out = append(out, b)
+				j++
+			default:
+				i++
+				j++
+			}
+		}
+		out = append(out, c.array[i:]...)
+		return fromArray(append(out, o.array[j:]...))
+	}
+	a, b := c.bitmap(), o.bitmap()
+	words := make([]uint64, roaringBitmapWords)
+	for i := range words {
+		words[i] = a[i] ^ b[i]
+	}
+	return fromBitmap(words)
+}
+
+// setBits sets bits first through last inclusive.
+func setBits(words []uint64, first, last uint16) {
+	for v := uint32(first); v <= uint32(last); {
+		w, off := v/64, v%64
+		span := min(64-off, uint32(last)-v+1)
+		words[w] |= (^uint64(0) >> (64 - span)) << off
+		v += span
+	}
+}
+
+// clearBits clears bits first through last inclusive.
+func clearBits(words []uint64, first, last uint16) {
+	for v := uint32(first); v <= uint32(last); {
+		w, off := v/64, v%64
+		span := min(64-off, uint32(last)-v+1)
+		words[w] &^= (^uint64(0) >> (64 - span)) << off
+		v += span
+	}
+}
+
+func popcount(words []uint64) int {
+	n := 0
+	for _, w := range words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Serialized format cookies, from the Roaring format specification.
+const (
+	roaringCookieNoRuns = 12346
+	roaringCookieRuns   = 12347
+	roaringNoOffsets    = 4 // run bitmaps with fewer containers omit offsets
+)
+
+// MarshalBinary encodes the bitmap in the portable Roaring format shared by
+// the Java, C and Go Roaring libraries, so serialized bitmaps can be
+// exchanged with them.
+func (r *RoaringBitmap) MarshalBinary() ([]byte, error) {
+	var keys []uint16
+	var cs []*roaringContainer
+	if r != nil {
+		keys, cs = r.keys, r.containers
+	}
+	hasRuns := slices.ContainsFunc(cs, func(c *roaringContainer) bool { return c.kind == roaringRun })
+
+	var out []byte
+	if hasRuns {
+		out = binary.LittleEndian.AppendUint32(out, roaringCookieRuns|uint32(len(cs)-1)<<16)
+		runFlags := make([]byte, (len(cs)+7)/8)
+		for i, c := range cs {
+			if c.kind == roaringRun {
+				runFlags[i/8] |= 1 << (i % 8)
+			}
+		}
+		out = append(out, runFlags...)
+	} else {
+		out = binary.LittleEndian.AppendUint32(out, roaringCookieNoRuns)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(cs)))
+	}
+	for i, c := range cs {
+		out = binary.LittleEndian.AppendUint16(out, keys[i])
+		out = binary.LittleEndian.AppendUint16(out, uint16(c.n-1))
+	}
+	if !hasRuns || len(cs) >= roaringNoOffsets {
+		offset := len(out) + 4*len(cs)
+		for _, c := range cs {
+			out = binary.LittleEndian.AppendUint32(out, uint32(offset))
+			offset += c.encodedLen()
+		}
+	}
+	for _, c := range cs {
+		switch c.kind {
+		case roaringArray:
+			for _, v := range c.array {
+				out = binary.LittleEndian.AppendUint16(out, v)
+			}
+		case roaringBitmap:
+			for _, w := range c.bits {
+				out = binary.LittleEndian.AppendUint64(out, w)
+			}
+		case roaringRun:
+			out = binary.LittleEndian.AppendUint16(out, uint16(len(c.runs)))
+			for _, run := range c.runs {
+				out = binary.LittleEndian.AppendUint16(out, run.start)
+				out = binary.LittleEndian.AppendUint16(out, run.last-run.start)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (c *roaringContainer) encodedLen() int {
+	switch c.kind {
+	case roaringArray:
+		return 2 * len(c.array)
+	case roaringBitmap:
+		return 8 * roaringBitmapWords
+	}
+	return 2 + 4*len(c.runs)
+}
+
+var errRoaringFormat = errors.New("collections: malformed Roaring bitmap data")
+
+// UnmarshalBinary replaces the contents of the bitmap with data in the
+// portable Roaring format, as produced by MarshalBinary or another Roaring
+// implementation.
+func (r *RoaringBitmap) UnmarshalBinary(data []byte) error {
+	if r == nil {
+		return errors.New("collections: UnmarshalBinary on nil *RoaringBitmap")
+	}
+	d := roaringDecoder{data: data}
+	cookie := d.uint32()
+	var size int
+	var runFlags []byte
+	switch {
+	case cookie&0xFFFF == roaringCookieRuns:
+		size = int(cookie>>16) + 1
+		runFlags = d.bytes((size + 7) / 8)
+	case cookie == roaringCookieNoRuns:
+		size = int(d.uint32())
+	default:
+		if d.err == nil {
+			return fmt.Errorf("collections: unknown Roaring cookie %#x", cookie)
+		}
+	}
+	if d.err != nil || size > 1<<16 {
+		return errRoaringFormat
+	}
+	keys := make([]uint16, size)
+	cards := make([]int, size)
+	for i := range keys {
+		keys[i] = d.uint16()
+		cards[i] = int(d.uint16()) + 1
+		if i > 0 && keys[i] <= keys[i-1] {
+			return errRoaringFormat
+		}
+	}
+	if runFlags == nil || size >= roaringNoOffsets {
+		d.bytes(4 * size) // offsets; containers are read sequentially
+	}
+	cs := make([]*roaringContainer, size)
+	for i := range cs {
+		c := &roaringContainer{n: cards[i]}
+		switch {
+		case runFlags != nil && runFlags[i/8]&(1<<(i%8)) != 0:
+			c.kind = roaringRun
+			c.runs = make([]roaringInterval, d.uint16())
+			n := 0
+			for j := range c.runs {
+				start, length := d.uint16(), d.uint16()
+				if uint32(start)+uint32(length) > 0xFFFF || (j > 0 && uint32(start) <= uint32(c.runs[j-1].last)+1) {
+					return errRoaringFormat
+				}
+				c.runs[j] = roaringInterval{start, start + length}
+				n += int(length) + 1
+			}
+			if n != c.n {
+				return errRoaringFormat
+			}
+		case c.n > roaringArrayMax:
+			c.kind = roaringBitmap
+			c.bits = make([]uint64, roaringBitmapWords)
+			for j := range c.bits {
+				c.bits[j] = d.uint64()
+			}
+			if d.err == nil && popcount(c.bits) != c.n {
+				return errRoaringFormat
+			}
+		default:
+			c.kind = roaringArray
+			c.array = make([]uint16, c.n)
+			for j := range c.array {
+				c.array[j] = d.uint16()
+				if j > 0 && c.array[j] <= c.array[j-1] {
+					return errRoaringFormat
+				}
+			}
+		}
+		if d.err != nil {
+			return errRoaringFormat
+		}
+		c.normalize()
+		cs[i] = c
+	}
+	r.keys, r.containers = keys, cs
+	return nil
+}
+
+// roaringDecoder reads little-endian values, recording an error instead of
+// panicking when the data runs out.
+type roaringDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *roaringDecoder) bytes(n int) []byte {
+	if d.err != nil || len(d.data) < n {
+		d.err = errRoaringFormat
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *roaringDecoder) uint16() uint16 { return binary.LittleEndian.Uint16(d.bytes(2)) }
+func (d *roaringDecoder) uint32() uint32 { return binary.LittleEndian.Uint32(d.bytes(4)) }
+func (d *roaringDecoder) uint64() uint64 { return binary.LittleEndian.Uint64(d.bytes(8)) }
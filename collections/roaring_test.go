@@ -0,0 +1,350 @@
+package collections
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestRoaringBitmapBasic(t *testing.T) {
+	var r RoaringBitmap
+	for _, v := range []uint32{70000, 1, 5, 1 << 31, 5, 65535} {
+		r.Add(v)
+	}
+	if got := r.Values(); !reflect.DeepEqual(got, []uint32{1, 5, 65535, 70000, 1 << 31}) {
+		t.Fatalf("values %v", got)
+	}
+	if r.Len() != 5 || !r.Has(70000) || r.Has(70001) || r.Has(6) {
+		t.Fatalf("len/has")
+	}
+	if v, ok := r.Min(); !ok || v != 1 {
+		t.Fatalf("min %d", v)
+	}
+	if v, ok := r.Max(); !ok || v != 1<<31 {
+		t.Fatalf("max %d", v)
+	}
+	r.Remove(70000)
+	r.Remove(70000)
+	r.Remove(42)
+	if r.Has(70000) || r.Len() != 4 || len(r.keys) != 2 {
+		t.Fatalf("remove left %v in %d chunks", r.Values(), len(r.keys))
+	}
+	r.Clear()
+	if r.Len() != 0 || r.Values() != nil {
+		t.Fatalf("clear")
+	}
+	if _, ok := r.Min(); ok {
+		t.Fatalf("min of empty bitmap")
+	}
+}
+
+func TestRoaringBitmapContainerConversions(t *testing.T) {
+	var r RoaringBitmap
+	for v := uint32(0); v < 2*roaringArrayMax; v += 2 {
+		r.Add(v)
+	}
+	if c := r.containers[0]; c.kind != roaringArray || c.n != roaringArrayMax {
+		t.Fatalf("kind %d n %d, want array at the limit", c.kind, c.n)
+	}
+	r.Add(1)
+	if c := r.containers[0]; c.kind != roaringBitmap {
+		t.Fatalf("expected bitmap container above %d values", roaringArrayMax)
+	}
+	r.Remove(1)
+	r.Remove(0)
+	if c := r.containers[0]; c.kind != roaringArray || c.n != roaringArrayMax-1 {
+		t.Fatalf("expected array container again, kind %d n %d", c.kind, c.n)
+	}
+}
+
+func TestRoaringBitmapRanges(t *testing.T) {
+	var r RoaringBitmap
+	r.AddRange(65530, 3*65536+10)
+	if got, want := r.Len(), 3*65536+10-65530; got != want {
+		t.Fatalf("len %d, want %d", got, want)
+	}
+	if c := r.containers[1]; c.kind != roaringRun {
+		t.Fatalf("full chunk should be a run, kind %d", c.kind)
+	}
+	if !r.Has(65530) || r.Has(65529) || !r.Has(3*65536+9) || r.Has(3*65536+10) {
+		t.Fatalf("range bounds")
+	}
+
+	r.RemoveRange(65536+100, 2*65536+200)
+	if r.Has(65536+100) || !r.Has(65536+99) || !r.Has(2*65536+200) || r.Has(2*65536+199) {
+		t.Fatalf("remove range bounds")
+	}
+	r.Remove(65536 + 50) // removing from what was a run container
+	if r.Has(65536+50) || !r.Has(65536+51) {
+		t.Fatalf("remove from run")
+	}
+	full := NewRoaringBitmap()
+	full.AddRange(0, 1<<16)
+	full.Remove(5)
+	if full.Has(5) || full.Len() != 1<<16-1 {
+		t.Fatalf("remove from a full run chunk: Has(5)=%v Len=%d", full.Has(5), full.Len())
+	}
+	checkRoaringInvariants(t, full)
+	full.Add(5)
+	if full.Len() != 1<<16 || full.containers[0].kind != roaringRun {
+		t.Fatalf("refilled chunk should be a run")
+	}
+	full.Remove(0)
+	if full.Has(0) || full.Len() != 1<<16-1 {
+		t.Fatalf("remove from a chunk filled by Add")
+	}
+	r.RemoveRange(0, 1<<32)
+	if r.Len() != 0 || len(r.keys) != 0 {
+		t.Fatalf("remove everything left %d", r.Len())
+	}
+
+	r.AddRange(1<<32-3, 1<<33)
+	if got := r.Values(); !reflect.DeepEqual(got, []uint32{1<<32 - 3, 1<<32 - 2, 1<<32 - 1}) {
+		t.Fatalf("range at the top %v", got)
+	}
+	r.AddRange(10, 5)
+	if r.Len() != 3 {
+		t.Fatalf("inverted range must be ignored")
+	}
+}
+
+func TestRoaringBitmapAlgebraMatchesSet(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() (*RoaringBitmap, *Set[uint32]) {
+		r, s := NewRoaringBitmap(), NewSet[uint32]()
+		// Mix sparse values, dense chunks and whole runs across a few chunks.
+		for range rng.Intn(3000) {
+			v := uint32(rng.Intn(4))<<16 | uint32(rng.Intn(1<<16))
+			r.Add(v)
+			s.Add(v)
+		}
+		if rng.Intn(2) == 0 {
+			lo := uint64(rng.Intn(4 << 16))
+			hi := lo + uint64(rng.Intn(100_000))
+			r.AddRange(lo, hi)
+			for v := lo; v < hi; v++ {
+				s.Add(uint32(v))
+			}
+		}
+		if rng.Intn(2) == 0 {
+			r.RunOptimize()
+		}
+		return r, s
+	}
+	same := func(r *RoaringBitmap, s *Set[uint32]) bool {
+		return r.Len() == s.Len() && s.ContainsAllSeq(r.All()) && slices.IsSorted(r.Values())
+	}
+	for round := 0; round < 40; round++ {
+		a, sa := random()
+		b, sb := random()
+		checks := []struct {
+			name string
+			got  *RoaringBitmap
+			want *Set[uint32]
+		}{
+			{"union", a.Union(b), sa.Union(sb)},
+			{"intersection", a.Intersection(b), sa.Intersection(sb)},
+			{"difference", a.Difference(b), sa.Difference(sb)},
+			{"symmetric difference", a.SymmetricDifference(b), sa.SymmetricDifference(sb)},
+		}
+		for _, c := range checks {
+			if !same(c.got, c.want) {
+				t.Fatalf("round %d %s: %d values, want %d", round, c.name, c.got.Len(), c.want.Len())
+			}
+			checkRoaringInvariants(t, c.got)
+		}
+		if a.IsSubset(b) != sa.IsSubset(sb) || a.IsDisjoint(b) != sa.IsDisjoint(sb) || a.Equal(b) != sa.Equal(sb) {
+			t.Fatalf("round %d predicates", round)
+		}
+		in, out := a.Intersection(b), a.Difference(b)
+		if rng.Intn(2) == 0 {
+			in.RunOptimize()
+			out.RunOptimize()
+		}
+		if !in.IsSubset(a) || !in.IsSubset(b) || !out.IsSubset(a) || !out.IsDisjoint(b) || !b.IsDisjoint(out) {
+			t.Fatalf("round %d predicates on derived bitmaps", round)
+		}
+		if in.Len() > 0 && (in.IsDisjoint(a) || a.IsDisjoint(in)) || out.Len() > 0 && out.IsSubset(b) {
+			t.Fatalf("round %d negative predicates on derived bitmaps", round)
+		}
+		if !a.Equal(a.Clone()) || !a.Union(b).Equal(b.Union(a)) {
+			t.Fatalf("round %d equality", round)
+		}
+	}
+}
+
+func checkRoaringInvariants(t *testing.T, r *RoaringBitmap) {
+	t.Helper()
+	for i, c := range r.containers {
+		if i > 0 && r.keys[i] <= r.keys[i-1] {
+			t.Fatalf("keys not sorted")
+		}
+		n := 0
+		for range c.all() {
+			n++
+		}
+		if n == 0 || n != c.n {
+			t.Fatalf("container %d has %d values, n=%d", i, n, c.n)
+		}
+		if (c.kind == roaringArray && c.n > roaringArrayMax) || (c.kind == roaringBitmap && c.n <= roaringArrayMax) {
+			t.Fatalf("container %d kind %d with %d values", i, c.kind, c.n)
+		}
+	}
+}
+
+func TestRoaringBitmapRunOptimize(t *testing.T) {
+	var r RoaringBitmap
+	r.AddRange(100, 20_000)
+	r.Add(30_000)
+	if r.containers[0].kind != roaringBitmap {
+		t.Fatalf("expected bitmap before optimizing")
+	}
+	r.RunOptimize()
+	if c := r.containers[0]; c.kind != roaringRun || len(c.runs) != 2 {
+		t.Fatalf("expected two runs, kind %d", c.kind)
+	}
+	for v := uint32(40_000); v < 50_000; v += 2 {
+		r.Add(v) // converts the run container back
+	}
+	r.RunOptimize()
+	if c := r.containers[0]; c.kind != roaringBitmap {
+		t.Fatalf("alternating values are smallest as a bitmap, kind %d", c.kind)
+	}
+	checkRoaringInvariants(t, &r)
+}
+
+func TestRoaringBitmapSerialization(t *testing.T) {
+	// Byte layouts from the Roaring format specification.
+	small := NewRoaringBitmapFromSlice([]uint32{1, 2, 3})
+	want := []byte{
+		0x3A, 0x30, 0, 0, // cookie 12346, no runs
+		1, 0, 0, 0, // one container
+		0, 0, 2, 0, // key 0, cardinality 3
+		16, 0, 0, 0, // offset of the container
+		1, 0, 2, 0, 3, 0,
+	}
+	if got, _ := small.MarshalBinary(); !bytes.Equal(got, want) {
+		t.Fatalf("array encoding\n got % x\nwant % x", got, want)
+	}
+
+	run := NewRoaringBitmap()
+	run.AddRange(1, 101)
+	run.RunOptimize()
+	want = []byte{
+		0x3B, 0x30, 0, 0, // cookie 12347, one container
+		1,           // run flags
+		0, 0, 99, 0, // key 0, cardinality 100
+		1, 0, 1, 0, 99, 0, // one run: start 1, length 100
+	}
+	if got, _ := run.MarshalBinary(); !bytes.Equal(got, want) {
+		t.Fatalf("run encoding\n got % x\nwant % x", got, want)
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	big := NewRoaringBitmap()
+	for range 20_000 {
+		big.Add(rng.Uint32() % (8 << 16))
+	}
+	big.AddRange(9<<16, 11<<16+5)
+	big.RunOptimize()
+	for _, r := range []*RoaringBitmap{NewRoaringBitmap(), small, run, big} {
+		data, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back RoaringBitmap
+		if err := back.UnmarshalBinary(data); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if !back.Equal(r) {
+			t.Fatalf("round trip lost values: %d vs %d", back.Len(), r.Len())
+		}
+		checkRoaringInvariants(t, &back)
+	}
+
+	data, _ := big.MarshalBinary()
+	for _, bad := range [][]byte{nil, {1, 2, 3, 4}, data[:len(data)-1], data[:20]} {
+		var back RoaringBitmap
+		if err := back.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("expected error for %d bytes", len(bad))
+		}
+	}
+}
+
+func TestRoaringBitmapNil(t *testing.T) {
+	var r *RoaringBitmap
+	r.Add(1)
+	r.AddRange(0, 10)
+	r.Remove(1)
+	r.RunOptimize()
+	if r.Has(1) || r.Len() != 0 || r.Values() != nil {
+		t.Fatalf("nil bitmap should behave as empty")
+	}
+	if r.Union(NewRoaringBitmapFromSlice([]uint32{3})).Len() != 1 || !r.Equal(NewRoaringBitmap()) {
+		t.Fatalf("nil algebra")
+	}
+	if data, _ := r.MarshalBinary(); len(data) != 8 {
+		t.Fatalf("nil encoding %d bytes", len(data))
+	}
+}
+
+func benchRoaringInputs() (*RoaringBitmap, *RoaringBitmap, *Set[uint32], *Set[uint32]) {
+	rng := rand.New(rand.NewSource(1))
+	a, b := NewRoaringBitmap(), NewRoaringBitmap()
+	sa, sb := NewSet[uint32](), NewSet[uint32]()
+	for range 200_000 {
+		x, y := rng.Uint32()%(1<<24), rng.Uint32()%(1<<24)
+		a.Add(x)
+		sa.Add(x)
+		b.Add(y)
+		sb.Add(y)
+	}
+	return a, b, sa, sb
+}
+
+func BenchmarkRoaringBitmapIntersection(b *testing.B) {
+	x, y, sx, sy := benchRoaringInputs()
+	b.Run("RoaringBitmap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			x.Intersection(y)
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sx.Intersection(sy)
+		}
+	})
+}
+
+func BenchmarkRoaringBitmapUnion(b *testing.B) {
+	x, y, sx, sy := benchRoaringInputs()
+	b.Run("RoaringBitmap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			x.Union(y)
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sx.Union(sy)
+		}
+	})
+}
+
+func BenchmarkRoaringBitmapAdd(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]uint32, 1<<16)
+	for i := range values {
+		values[i] = rng.Uint32()
+	}
+	b.ReportAllocs()
+	r := NewRoaringBitmap()
+	for i := 0; i < b.N; i++ {
+		r.Add(values[i&(len(values)-1)])
+	}
+}
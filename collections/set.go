@@ -1,6 +1,17 @@
 package collections
 
-import "iter"
+import (
+	"bytes"
+	"cmp"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
 
 type Set[T comparable] struct {
 	m map[T]struct{}
@@ -198,6 +209,81 @@ func (s *Set[T]) SymmetricDifference(other *Set[T]) *Set[T] {
 	return out
 }
 
+// UnionWith adds the elements of other to s and reports whether s changed.
+// Unlike Union it modifies s in place and does not allocate a new set.
+func (s *Set[T]) UnionWith(other *Set[T]) bool {
+	if s == nil || other == nil || s == other {
+		return false
+	}
+	s.ensure()
+	n := len(s.m)
+	for v := range other.m {
+		s.m[v] = struct{}{}
+	}
+	return len(s.m) != n
+}
+
+// IntersectWith removes the elements of s that are not in other and reports
+// whether s changed.
+func (s *Set[T]) IntersectWith(other *Set[T]) bool {
+	if s == nil || s == other {
+		return false
+	}
+	n := len(s.m)
+	for v := range s.m {
+		if !other.Has(v) {
+			delete(s.m, v)
+		}
+	}
+	return len(s.m) != n
+}
+
+// DifferenceWith removes the elements of other from s and reports whether s
+// changed.
+func (s *Set[T]) DifferenceWith(other *Set[T]) bool {
+	if s == nil || other == nil || len(s.m) == 0 {
+		return false
+	}
+	n := len(s.m)
+	if s == other {
+		clear(s.m)
+		return n > 0
+	}
+	if len(other.m) < n {
+		for v := range other.m {
+			delete(s.m, v)
+		}
+	} else {
+		for v := range s.m {
+			if _, ok := other.m[v]; ok {
+				delete(s.m, v)
+			}
+		}
+	}
+	return len(s.m) != n
+}
+
+// SymmetricDifferenceWith replaces s with the elements that are in exactly
+// one of s and other, and reports whether s changed.
+func (s *Set[T]) SymmetricDifferenceWith(other *Set[T]) bool {
+	if s == nil || other.Len() == 0 {
+		return false
+	}
+	if s == other {
+		clear(s.m)
+		return true
+	}
+	s.ensure()
+	for v := range other.m {
+		if _, ok := s.m[v]; ok {
+			delete(s.m, v)
+		} else {
+			s.m[v] = struct{}{}
+		}
+	}
+	return true
+}
+
 func (s *Set[T]) IsSubset(other *Set[T]) bool {
 	if s == nil {
 		return true
@@ -231,3 +317,258 @@ func (s *Set[T]) IsDisjoint(other *Set[T]) bool {
 	}
 	return true
 }
+
+// Equal reports whether s and other contain the same elements. A nil set
+// equals an empty one.
+func (s *Set[T]) Equal(other *Set[T]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
+
+// ContainsAll reports whether every one of vs is in the set. It reports true
+// when vs is empty.
+func (s *Set[T]) ContainsAll(vs ...T) bool {
+	return s.ContainsAllSeq(slices.Values(vs))
+}
+
+// ContainsAllSeq reports whether every element of seq is in the set,
+// stopping at the first one that is not.
+func (s *Set[T]) ContainsAllSeq(seq iter.Seq[T]) bool {
+	for v := range seq {
+		if !s.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether at least one of vs is in the set. It reports
+// false when vs is empty.
+func (s *Set[T]) ContainsAny(vs ...T) bool {
+	return s.ContainsAnySeq(slices.Values(vs))
+}
+
+// ContainsAnySeq reports whether at least one element of seq is in the set,
+// stopping at the first one that is.
+func (s *Set[T]) ContainsAnySeq(seq iter.Seq[T]) bool {
+	for v := range seq {
+		if s.Has(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAll adds each of vs to the set.
+func (s *Set[T]) AddAll(vs ...T) {
+	s.AddSeq(slices.Values(vs))
+}
+
+// AddSeq adds every element of seq to the set.
+func (s *Set[T]) AddSeq(seq iter.Seq[T]) {
+	if s == nil {
+		return
+	}
+	s.ensure()
+	for v := range seq {
+		s.m[v] = struct{}{}
+	}
+}
+
+// RemoveAll removes each of vs from the set.
+func (s *Set[T]) RemoveAll(vs ...T) {
+	s.RemoveSeq(slices.Values(vs))
+}
+
+// RemoveSeq removes every element of seq from the set.
+func (s *Set[T]) RemoveSeq(seq iter.Seq[T]) {
+	if s == nil || s.m == nil {
+		return
+	}
+	for v := range seq {
+		delete(s.m, v)
+	}
+}
+
+// RemoveFunc removes the elements for which fn returns true and returns the
+// number removed.
+func (s *Set[T]) RemoveFunc(fn func(T) bool) int {
+	if s == nil || s.m == nil {
+		return 0
+	}
+	n := len(s.m)
+	for v := range s.m {
+		if fn(v) {
+			delete(s.m, v)
+		}
+	}
+	return n - len(s.m)
+}
+
+// RetainFunc keeps only the elements for which fn returns true and returns
+// the number removed.
+func (s *Set[T]) RetainFunc(fn func(T) bool) int {
+	return s.RemoveFunc(func(v T) bool { return !fn(v) })
+}
+
+// Pop removes and returns an arbitrary element of the set.
+func (s *Set[T]) Pop() (T, bool) {
+	if s != nil {
+		for v := range s.m {
+			delete(s.m, v)
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// MarshalJSON encodes the set as a JSON array. Element order is undefined;
+// use SortedJSON for deterministic output. A nil set encodes as null.
+func (s *Set[T]) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	values := s.Values()
+	if values == nil {
+		values = []T{}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements of a JSON
+// array, dropping duplicates. A JSON null leaves the set unchanged.
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	if s == nil {
+		return errors.New("collections: UnmarshalJSON on nil *Set")
+	}
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		return nil
+	}
+	s.replace(values)
+	return nil
+}
+
+// SortedJSON returns a json.Marshaler that encodes s as a JSON array in
+// ascending order, for stable API responses and golden files.
+func SortedJSON[T cmp.Ordered](s *Set[T]) json.Marshaler {
+	return sortedSetJSON[T]{s}
+}
+
+type sortedSetJSON[T cmp.Ordered] struct{ s *Set[T] }
+
+func (j sortedSetJSON[T]) MarshalJSON() ([]byte, error) {
+	if j.s == nil {
+		return []byte("null"), nil
+	}
+	values := j.s.Values()
+	if values == nil {
+		values = []T{}
+	}
+	slices.Sort(values)
+	return json.Marshal(values)
+}
+
+// TextSet wraps a set of strings to implement encoding.TextMarshaler and
+// encoding.TextUnmarshaler, for use with flag.TextVar, environment or config
+// decoders. The text form lists the elements in ascending order separated by
+// commas; an element that is empty or contains a comma or a double quote is
+// written as a Go quoted string, so `a,"b,c",""` holds "a", "b,c" and "".
+// An empty set encodes as empty text.
+type TextSet[T ~string] struct {
+	*Set[T]
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (t TextSet[T]) MarshalText() ([]byte, error) {
+	values := t.Set.Values()
+	slices.Sort(values)
+	var buf []byte
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if v == "" || strings.ContainsAny(string(v), `,"`) {
+			buf = strconv.AppendQuote(buf, string(v))
+		} else {
+			buf = append(buf, v...)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. It
+// replaces the contents of the set, allocating one if t.Set is nil.
+func (t *TextSet[T]) UnmarshalText(text []byte) error {
+	var values []T
+	for rest := string(text); rest != ""; {
+		var v string
+		if rest[0] == '"' {
+			q, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fmt.Errorf("collections: invalid quoted Set element in %q", text)
+			}
+			v, _ = strconv.Unquote(q)
+			rest = rest[len(q):]
+			if rest != "" && rest[0] != ',' {
+				return fmt.Errorf("collections: missing comma after quoted Set element in %q", text)
+			}
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			v = rest[:end]
+			if strings.Contains(v, `"`) {
+				return fmt.Errorf("collections: unquoted Set element %q contains a double quote", v)
+			}
+			rest = rest[end:]
+		}
+		values = append(values, T(v))
+		if rest != "" {
+			// Skip the comma; a trailing one leaves an empty element.
+			if rest = rest[1:]; rest == "" {
+				values = append(values, "")
+			}
+		}
+	}
+	if t.Set == nil {
+		t.Set = NewSet[T]()
+	}
+	t.Set.replace(values)
+	return nil
+}
+
+// GobEncode encodes the elements of the set with encoding/gob.
+func (s *Set[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s.Values()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the contents of the set with elements decoded by
+// encoding/gob.
+func (s *Set[T]) GobDecode(data []byte) error {
+	if s == nil {
+		return errors.New("collections: GobDecode on nil *Set")
+	}
+	var values []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return err
+	}
+	s.replace(values)
+	return nil
+}
+
+// replace clears the set and adds values.
+func (s *Set[T]) replace(values []T) {
+	s.m = make(map[T]struct{}, len(values))
+	for _, v := range values {
+		s.m[v] = struct{}{}
+	}
+}
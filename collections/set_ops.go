@@ -0,0 +1,146 @@
+package collections
+
+import (
+	"iter"
+	"slices"
+)
+
+// UnionAll returns a new set with the elements that are in any of the sets.
+// Nil sets are treated as empty.
+func UnionAll[T comparable](sets ...*Set[T]) *Set[T] {
+	largest := -1
+	for i, s := range sets {
+		if largest < 0 || s.Len() > sets[largest].Len() {
+			largest = i
+		}
+	}
+	if largest < 0 {
+		return NewSet[T]()
+	}
+	out := sets[largest].Clone()
+	for i, s := range sets {
+		if i != largest {
+			out.UnionWith(s)
+		}
+	}
+	return out
+}
+
+// IntersectAll returns a new set with the elements that are in every one of
+// the sets. Membership is checked starting from the smallest set, and an
+// empty or nil input short-circuits to an empty result. With no sets the
+// result is empty.
+func IntersectAll[T comparable](sets ...*Set[T]) *Set[T] {
+	sorted, ok := bySize(sets)
+	if !ok {
+		return NewSet[T]()
+	}
+	out := NewSetWithCapacity[T](sorted[0].Len())
+	for v := range sorted[0].m {
+		if inAll(v, sorted[1:]) {
+			out.m[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// DifferenceAll returns a new set with the elements of s that are in none of
+// the others.
+func DifferenceAll[T comparable](s *Set[T], others ...*Set[T]) *Set[T] {
+	out := NewSet[T]()
+	if s == nil {
+		return out
+	}
+	for v := range s.m {
+		if !inAny(v, others) {
+			out.m[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// UnionSeq returns an iterator over the elements that are in any of the
+// sets, each produced once, without building the union. Elements are
+// produced in undefined order. The sets must not be modified during
+// iteration.
+func UnionSeq[T comparable](sets ...*Set[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i, s := range sets {
+			if s == nil {
+				continue
+			}
+			for v := range s.m {
+				if inAny(v, sets[:i]) {
+					continue
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// IntersectSeq returns an iterator over the elements that are in every one
+// of the sets, without building the intersection. It walks the smallest set
+// and checks the others in ascending order of size. Elements are produced in
+// undefined order. The sets must not be modified during iteration.
+func IntersectSeq[T comparable](sets ...*Set[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		sorted, ok := bySize(sets)
+		if !ok {
+			return
+		}
+		for v := range sorted[0].m {
+			if inAll(v, sorted[1:]) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// DifferenceSeq returns an iterator over the elements of s that are in none
+// of the others, without building the difference. Elements are produced in
+// undefined order. The sets must not be modified during iteration.
+func DifferenceSeq[T comparable](s *Set[T], others ...*Set[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s == nil {
+			return
+		}
+		for v := range s.m {
+			if !inAny(v, others) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// bySize returns a copy of sets ordered by ascending Len. It reports false
+// if there are no sets or the smallest one is empty, in which case any
+// intersection is empty.
+func bySize[T comparable](sets []*Set[T]) ([]*Set[T], bool) {
+	if len(sets) == 0 {
+		return nil, false
+	}
+	sorted := slices.Clone(sets)
+	slices.SortFunc(sorted, func(a, b *Set[T]) int { return a.Len() - b.Len() })
+	return sorted, sorted[0].Len() > 0
+}
+
+func inAll[T comparable](v T, sets []*Set[T]) bool {
+	for _, s := range sets {
+		if _, ok := s.m[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func inAny[T comparable](v T, sets []*Set[T]) bool {
+	for _, s := range sets {
+		if s.Has(v) {
+			return true
+		}
+	}
+	return false
+}
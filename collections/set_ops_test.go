@@ -0,0 +1,94 @@
+package collections
+
+import (
+	"iter"
+	"slices"
+	"testing"
+)
+
+func sortedValues[T int | string](seq iter.Seq[T]) []T {
+	var out []T
+	for v := range seq {
+		out = append(out, v)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestSetNaryOps(t *testing.T) {
+	a := NewSetFromSlice([]int{1, 2, 3, 4})
+	b := NewSetFromSlice([]int{2, 3, 4, 5})
+	c := NewSetFromSlice([]int{3, 4, 6})
+
+	cases := []struct {
+		name  string
+		eager *Set[int]
+		lazy  iter.Seq[int]
+		want  []int
+	}{
+		{"union", UnionAll(a, b, c), UnionSeq(a, b, c), []int{1, 2, 3, 4, 5, 6}},
+		{"union with nil", UnionAll(nil, a), UnionSeq(nil, a), []int{1, 2, 3, 4}},
+		{"union of none", UnionAll[int](), UnionSeq[int](), nil},
+		{"intersect", IntersectAll(a, b, c), IntersectSeq(a, b, c), []int{3, 4}},
+		{"intersect one", IntersectAll(a), IntersectSeq(a), []int{1, 2, 3, 4}},
+		{"intersect with empty", IntersectAll(a, NewSet[int](), b), IntersectSeq(a, NewSet[int](), b), nil},
+		{"intersect with nil", IntersectAll(a, nil), IntersectSeq(a, nil), nil},
+		{"intersect none", IntersectAll[int](), IntersectSeq[int](), nil},
+		{"difference", DifferenceAll(a, b, c), DifferenceSeq(a, b, c), []int{1}},
+		{"difference with nil", DifferenceAll(a, nil), DifferenceSeq(a, nil), []int{1, 2, 3, 4}},
+		{"difference of nil", DifferenceAll(nil, a), DifferenceSeq(nil, a), nil},
+	}
+	for _, c := range cases {
+		if got := sortedValues(c.eager.All()); !slices.Equal(got, c.want) {
+			t.Fatalf("%s: eager %v, want %v", c.name, got, c.want)
+		}
+		if got := sortedValues(c.lazy); !slices.Equal(got, c.want) {
+			t.Fatalf("%s: lazy %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if a.Len() != 4 || b.Len() != 4 || c.Len() != 3 {
+		t.Fatalf("inputs must not be modified")
+	}
+}
+
+func TestSetNarySeqEarlyExit(t *testing.T) {
+	a := NewSetFromSlice([]int{1, 2, 3})
+	b := NewSetFromSlice([]int{3, 4, 5})
+	for _, seq := range []iter.Seq[int]{UnionSeq(a, b), IntersectSeq(a, a), DifferenceSeq(a, b)} {
+		n := 0
+		for range seq {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Fatalf("expected a single element before break, got %d", n)
+		}
+	}
+}
+
+func BenchmarkSetIntersectAll(b *testing.B) {
+	sets := benchSets(100)
+	b.Run("pairwise", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := sets[0]
+			for _, s := range sets[1:] {
+				acc = acc.Intersection(s)
+			}
+		}
+	})
+	b.Run("IntersectAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			IntersectAll(sets...)
+		}
+	})
+	b.Run("IntersectSeq", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for range IntersectSeq(sets...) {
+			}
+		}
+	})
+}
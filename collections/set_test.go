@@ -1,6 +1,11 @@
 package collections
 
-import "testing"
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
 
 func TestSetBasic(t *testing.T) {
 	s := NewSet[int]()
@@ -145,3 +150,339 @@ func TestSetIterator(t *testing.T) {
 		t.Errorf("got %d want 2", count)
 	}
 }
+
+func TestSetJSON(t *testing.T) {
+	s := NewSetFromSlice([]int{3, 1, 2})
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Set[int]
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Len() != 3 || !back.IsSubset(s) {
+		t.Fatalf("round trip %s -> %v", data, back.Values())
+	}
+
+	if data, _ := json.Marshal(NewSet[int]()); string(data) != "[]" {
+		t.Fatalf("empty set %s", data)
+	}
+	if data, _ := json.Marshal(SortedJSON(s)); string(data) != "[1,2,3]" {
+		t.Fatalf("sorted %s", data)
+	}
+	if data, _ := json.Marshal(SortedJSON[string](nil)); string(data) != "null" {
+		t.Fatalf("sorted nil %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`[4,4,5]`), &back); err != nil || back.Len() != 2 || !back.Has(4) || back.Has(1) {
+		t.Fatalf("unmarshal replaces contents: %v %v", err, back.Values())
+	}
+	if err := json.Unmarshal([]byte(`null`), &back); err != nil || back.Len() != 2 {
+		t.Fatalf("null should leave the set unchanged")
+	}
+	if err := json.Unmarshal([]byte(`["x"]`), &back); err == nil {
+		t.Fatalf("expected type error")
+	}
+}
+
+type colour string
+
+func TestTextSet(t *testing.T) {
+	s := NewSetFromSlice([]colour{"red", "blue", "green"})
+	text, err := TextSet[colour]{s}.MarshalText()
+	if err != nil || string(text) != "blue,green,red" {
+		t.Fatalf("marshal text %q %v", text, err)
+	}
+	var back TextSet[colour]
+	if err := back.UnmarshalText(text); err != nil || back.Len() != 3 || !back.Has("green") {
+		t.Fatalf("unmarshal text %v %v", back.Values(), err)
+	}
+	if err := back.UnmarshalText(nil); err != nil || back.Len() != 0 {
+		t.Fatalf("empty text %v %v", back.Values(), err)
+	}
+
+	for _, values := range [][]string{
+		nil,
+		{""},
+		{"", "a"},
+		{"a,b", `say "hi"`, "c"},
+		{",", `"`, " "},
+	} {
+		text, err := TextSet[string]{NewSetFromSlice(values)}.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back TextSet[string]
+		if err := back.UnmarshalText(text); err != nil {
+			t.Fatalf("unmarshal %q: %v", text, err)
+		}
+		if back.Len() != len(values) || !back.IsSuperset(NewSetFromSlice(values)) {
+			t.Fatalf("%q round trip via %q gave %q", values, text, back.Values())
+		}
+	}
+	if text, _ := (TextSet[string]{NewSetFromSlice([]string{"", "b,c", "a"})}).MarshalText(); string(text) != `"",a,"b,c"` {
+		t.Fatalf("quoting %s", text)
+	}
+	if text, _ := (TextSet[string]{}).MarshalText(); len(text) != 0 {
+		t.Fatalf("nil set %q", text)
+	}
+
+	for _, bad := range []string{`"a`, `"a"b`, `a"b`} {
+		if err := back.UnmarshalText([]byte(bad)); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSetGob(t *testing.T) {
+	type record struct {
+		Name string
+		Tags *Set[string]
+		Ids  *Set[int]
+	}
+	in := record{Name: "r", Tags: NewSetFromSlice([]string{"a", "b"}), Ids: NewSet[int]()}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out record
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "r" || out.Tags.Len() != 2 || !out.Tags.Has("b") || out.Ids.Len() != 0 {
+		t.Fatalf("gob round trip %+v", out)
+	}
+}
+
+func TestSetInPlaceAlgebra(t *testing.T) {
+	of := func(vs ...int) *Set[int] { return NewSetFromSlice(vs) }
+	cases := []struct {
+		name    string
+		op      func(s, other *Set[int]) bool
+		copying func(s, other *Set[int]) *Set[int]
+	}{
+		{"union", (*Set[int]).UnionWith, (*Set[int]).Union},
+		{"intersect", (*Set[int]).IntersectWith, (*Set[int]).Intersection},
+		{"difference", (*Set[int]).DifferenceWith, (*Set[int]).Difference},
+		{"symmetric difference", (*Set[int]).SymmetricDifferenceWith, (*Set[int]).SymmetricDifference},
+	}
+	pairs := [][2]*Set[int]{
+		{of(1, 2, 3), of(2, 3, 4)},
+		{of(1, 2, 3), of(1, 2, 3)},
+		{of(1, 2, 3, 4, 5), of(5)},
+		{of(), of(1)},
+		{of(1), of()},
+		{of(1), nil},
+	}
+	for _, c := range cases {
+		for _, p := range pairs {
+			s := p[0].Clone()
+			want := c.copying(p[0], p[1])
+			changed := c.op(s, p[1])
+			if s.Len() != want.Len() || !s.IsSubset(want) {
+				t.Fatalf("%s(%v, %v) = %v, want %v", c.name, p[0].Values(), p[1].Values(), s.Values(), want.Values())
+			}
+			if wantChanged := s.Len() != p[0].Len() || !s.IsSubset(p[0]); changed != wantChanged {
+				t.Fatalf("%s(%v, %v) changed = %v, want %v", c.name, p[0].Values(), p[1].Values(), changed, wantChanged)
+			}
+		}
+	}
+}
+
+func TestSetInPlaceAlgebraAliased(t *testing.T) {
+	s := NewSetFromSlice([]int{1, 2})
+	if s.UnionWith(s) || s.IntersectWith(s) || s.Len() != 2 {
+		t.Fatalf("union/intersect with self must not change the set")
+	}
+	if !s.SymmetricDifferenceWith(s) || s.Len() != 0 {
+		t.Fatalf("symmetric difference with self must empty the set")
+	}
+	s.Add(1)
+	if !s.DifferenceWith(s) || s.Len() != 0 {
+		t.Fatalf("difference with self must empty the set")
+	}
+
+	var nilSet *Set[int]
+	if nilSet.UnionWith(s) || nilSet.IntersectWith(s) || nilSet.DifferenceWith(s) || nilSet.SymmetricDifferenceWith(s) {
+		t.Fatalf("nil receiver must report no change")
+	}
+	var zero Set[int]
+	if !zero.UnionWith(NewSetFromSlice([]int{1})) || !zero.Has(1) {
+		t.Fatalf("union into zero value")
+	}
+}
+
+// benchSets returns sets of 64 ints drawn from a range of 1024.
+func benchSets(n int) []*Set[int] {
+	sets := make([]*Set[int], n)
+	for i := range sets {
+		s := NewSetWithCapacity[int](64)
+		for j := 0; j < 64; j++ {
+			s.Add((i*37 + j*13) % 1024)
+		}
+		sets[i] = s
+	}
+	return sets
+}
+
+func BenchmarkSetUnionFold(b *testing.B) {
+	sets := benchSets(1000)
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := NewSet[int]()
+			for _, s := range sets {
+				acc = acc.Union(s)
+			}
+		}
+	})
+	b.Run("in-place", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := NewSet[int]()
+			for _, s := range sets {
+				acc.UnionWith(s)
+			}
+		}
+	})
+}
+
+func BenchmarkSetIntersectFold(b *testing.B) {
+	sets := benchSets(1000)
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := sets[0].Clone()
+			for _, s := range sets[1:] {
+				acc = acc.Intersection(s)
+			}
+		}
+	})
+	b.Run("in-place", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := sets[0].Clone()
+			for _, s := range sets[1:] {
+				acc.IntersectWith(s)
+			}
+		}
+	})
+}
+
+func BenchmarkSetDifferenceFold(b *testing.B) {
+	sets := benchSets(1000)
+	all := NewSet[int]()
+	for v := range 1024 {
+		all.Add(v)
+	}
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := all.Clone()
+			for _, s := range sets {
+				acc = acc.Difference(s)
+			}
+		}
+	})
+	b.Run("in-place", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc := all.Clone()
+			for _, s := range sets {
+				acc.DifferenceWith(s)
+			}
+		}
+	})
+}
+
+func TestSetEqual(t *testing.T) {
+	a := NewSetFromSlice([]int{1, 2, 3})
+	if !a.Equal(NewSetFromSlice([]int{3, 2, 1})) {
+		t.Fatalf("equal sets")
+	}
+	if a.Equal(NewSetFromSlice([]int{1, 2})) || a.Equal(NewSetFromSlice([]int{1, 2, 4})) {
+		t.Fatalf("unequal sets")
+	}
+	var nilSet *Set[int]
+	if !nilSet.Equal(NewSet[int]()) || !NewSet[int]().Equal(nilSet) || nilSet.Equal(a) {
+		t.Fatalf("nil equality")
+	}
+}
+
+func TestSetContains(t *testing.T) {
+	s := NewSetFromSlice([]string{"a", "b", "c"})
+	if !s.ContainsAll("a", "c") || s.ContainsAll("a", "z") || !s.ContainsAll() {
+		t.Fatalf("contains all")
+	}
+	if !s.ContainsAny("z", "b") || s.ContainsAny("y", "z") || s.ContainsAny() {
+		t.Fatalf("contains any")
+	}
+	other := NewSetFromSlice([]string{"b", "c"})
+	if !s.ContainsAllSeq(other.All()) || !s.ContainsAnySeq(other.All()) {
+		t.Fatalf("seq forms")
+	}
+	var nilSet *Set[string]
+	if nilSet.ContainsAny("a") || nilSet.ContainsAll("a") || !nilSet.ContainsAll() {
+		t.Fatalf("nil set")
+	}
+}
+
+func TestSetBulkAddRemove(t *testing.T) {
+	var s Set[int]
+	s.AddAll(1, 2, 3)
+	s.AddAll([]int{3, 4}...)
+	s.AddSeq(NewSetFromSlice([]int{5, 6}).All())
+	if s.Len() != 6 {
+		t.Fatalf("len %d", s.Len())
+	}
+	s.RemoveAll(1, 9)
+	s.RemoveSeq(NewSetFromSlice([]int{5, 6}).All())
+	if !s.Equal(NewSetFromSlice([]int{2, 3, 4})) {
+		t.Fatalf("values %v", s.Values())
+	}
+
+	var nilSet *Set[int]
+	nilSet.AddAll(1)
+	nilSet.RemoveAll(1)
+	nilSet.AddSeq(s.All())
+	if nilSet.Len() != 0 {
+		t.Fatalf("nil set")
+	}
+}
+
+func TestSetRemoveRetainFunc(t *testing.T) {
+	s := NewSetFromSlice([]int{1, 2, 3, 4, 5, 6})
+	if n := s.RemoveFunc(func(v int) bool { return v%2 == 0 }); n != 3 {
+		t.Fatalf("removed %d", n)
+	}
+	if n := s.RetainFunc(func(v int) bool { return v > 1 }); n != 1 {
+		t.Fatalf("retain removed %d", n)
+	}
+	if !s.Equal(NewSetFromSlice([]int{3, 5})) {
+		t.Fatalf("values %v", s.Values())
+	}
+	var nilSet *Set[int]
+	if nilSet.RemoveFunc(func(int) bool { return true }) != 0 {
+		t.Fatalf("nil set")
+	}
+}
+
+func TestSetPop(t *testing.T) {
+	s := NewSetFromSlice([]int{1, 2})
+	seen := NewSet[int]()
+	for {
+		v, ok := s.Pop()
+		if !ok {
+			break
+		}
+		seen.Add(v)
+	}
+	if !seen.Equal(NewSetFromSlice([]int{1, 2})) || s.Len() != 0 {
+		t.Fatalf("popped %v", seen.Values())
+	}
+	var nilSet *Set[int]
+	if _, ok := nilSet.Pop(); ok {
+		t.Fatalf("nil pop")
+	}
+}
@@ -0,0 +1,617 @@
+package collections
+
+import (
+	"cmp"
+	"iter"
+)
+
+// SortedMap is a map that keeps its keys in sorted order.
+//
+// It is backed by an AVL tree whose nodes also record subtree sizes, so Get,
+// Set, Delete, Floor, Ceiling and Rank are O(log n), and iterating over n
+// entries is O(n). Keys are ordered by cmp.Compare; use SortedMapFunc for a
+// custom ordering. The zero value is an empty map ready to use, and methods on
+// a nil *SortedMap behave like an empty map.
+//
+// Modifying the map while iterating is safe: iteration resumes after the last
+// key it produced, so keys inserted ahead of that point are produced and keys
+// deleted before they are reached are not.
+type SortedMap[K cmp.Ordered, V any] struct {
+	f SortedMapFunc[K, V]
+}
+
+// SortedMapFunc is a SortedMap ordered by a comparison function, for keys
+// that are not cmp.Ordered or need a different order. It has the same
+// methods and guarantees as SortedMap, but a zero-value SortedMapFunc has no
+// ordering: it must be created with NewSortedMapFunc, and Set on the zero
+// value panics. Methods on a nil *SortedMapFunc behave like an empty map.
+type SortedMapFunc[K any, V any] struct {
+	root    *sortedNode[K, V]
+	cmp     func(a, b K) int
+	version uint64 // bumped on every structural change
+}
+
+type sortedNode[K any, V any] struct {
+	key         K
+	value       V
+	left, right *sortedNode[K, V]
+	height      int8
+	size        int
+}
+
+// NewSortedMap creates an empty map ordered by cmp.Compare on its keys.
+func NewSortedMap[K cmp.Ordered, V any]() *SortedMap[K, V] {
+	return &SortedMap[K, V]{f: SortedMapFunc[K, V]{cmp: cmp.Compare[K]}}
+}
+
+// Len returns the number of entries. Complexity: O(1).
+func (m *SortedMap[K, V]) Len() int {
+	return m.tree().Len()
+}
+
+// Get returns the value for the key.
+func (m *SortedMap[K, V]) Get(k K) (V, bool) {
+	return m.tree().Get(k)
+}
+
+// Has reports whether the key is present.
+func (m *SortedMap[K, V]) Has(k K) bool {
+	return m.tree().Has(k)
+}
+
+// Set inserts or updates the value for the key.
+func (m *SortedMap[K, V]) Set(k K, v V) {
+	if m == nil {
+		return
+	}
+	if m.f.cmp == nil {
+		m.f.cmp = cmp.Compare[K]
+	}
+	m.f.Set(k, v)
+}
+
+// Delete removes the key if present.
+func (m *SortedMap[K, V]) Delete(k K) bool {
+	return m.tree().Delete(k)
+}
+
+// Clear removes all entries.
+func (m *SortedMap[K, V]) Clear() {
+	m.tree().Clear()
+}
+
+// Min returns the entry with the smallest key.
+func (m *SortedMap[K, V]) Min() (K, V, bool) {
+	return m.tree().Min()
+}
+
+// Max returns the entry with the largest key.
+func (m *SortedMap[K, V]) Max() (K, V, bool) {
+	return m.tree().Max()
+}
+
+// Floor returns the entry with the largest key less than or equal to k.
+func (m *SortedMap[K, V]) Floor(k K) (K, V, bool) {
+	return m.tree().Floor(k)
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to k.
+func (m *SortedMap[K, V]) Ceiling(k K) (K, V, bool) {
+	return m.tree().Ceiling(k)
+}
+
+// Rank returns the number of keys strictly less than k, which is the
+// position k has or would have in iteration order.
+func (m *SortedMap[K, V]) Rank(k K) int {
+	return m.tree().Rank(k)
+}
+
+// Select returns the entry at position i in ascending key order, the
+// inverse of Rank. It reports false if i is out of range.
+func (m *SortedMap[K, V]) Select(i int) (K, V, bool) {
+	return m.tree().Select(i)
+}
+
+// Keys returns the keys in ascending order.
+func (m *SortedMap[K, V]) Keys() []K {
+	return m.tree().Keys()
+}
+
+// Values returns the values in ascending key order.
+func (m *SortedMap[K, V]) Values() []V {
+	return m.tree().Values()
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+func (m *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.tree().All()
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+func (m *SortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return m.tree().Backward()
+}
+
+// Range returns an iterator over the entries with lo <= key < hi in
+// ascending order.
+func (m *SortedMap[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return m.tree().Range(lo, hi)
+}
+
+func (m *SortedMap[K, V]) tree() *SortedMapFunc[K, V] {
+	if m == nil {
+		return nil
+	}
+	return &m.f
+}
+
+// NewSortedMapFunc creates an empty map ordered by the comparison function,
+// which must return a negative number when a < b, zero when a == b and a
+// positive number when a > b, as for slices.SortFunc.
+func NewSortedMapFunc[K any, V any](cmp func(a, b K) int) *SortedMapFunc[K, V] {
+	return &SortedMapFunc[K, V]{cmp: cmp}
+}
+
+// Len returns the number of entries. Complexity: O(1).
+func (m *SortedMapFunc[K, V]) Len() int {
+	if m == nil {
+		return 0
+	}
+	return m.root.len()
+}
+
+// Get returns the value for the key.
+func (m *SortedMapFunc[K, V]) Get(k K) (V, bool) {
+	if n := m.find(k); n != nil {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Has reports whether the key is present.
+func (m *SortedMapFunc[K, V]) Has(k K) bool {
+	return m.find(k) != nil
+}
+
+// Set inserts or updates the value for the key. It panics on a zero-value
+// SortedMapFunc, which has no ordering.
+func (m *SortedMapFunc[K, V]) Set(k K, v V) {
+	if m == nil {
+		return
+	}
+	if m.cmp == nil {
+		panic("collections: zero-value SortedMapFunc has no ordering; create it with NewSortedMapFunc")
+	}
+	var inserted bool
+	m.root = m.insert(m.root, k, v, &inserted)
+	if inserted {
+		m.version++
+	}
+}
+
+// Delete removes the key if present.
+func (m *SortedMapFunc[K, V]) Delete(k K) bool {
+	if m == nil || m.root == nil {
+		return false
+	}
+	var deleted bool
+	m.root = m.remove(m.root, k, &deleted)
+	if deleted {
+		m.version++
+	}
+	return deleted
+}
+
+// Clear removes all entries.
+func (m *SortedMapFunc[K, V]) Clear() {
+	if m == nil {
+		return
+	}
+	m.root = nil
+	m.version++
+}
+
+// Min returns the entry with the smallest key.
+func (m *SortedMapFunc[K, V]) Min() (K, V, bool) {
+	if m == nil || m.root == nil {
+		return zeroEntry[K, V]()
+	}
+	n := m.root
+	for n.left != nil {
+		n = n.left
+	}
+	return n.key, n.value, true
+}
+
+// Max returns the entry with the largest key.
+func (m *SortedMapFunc[K, V]) Max() (K, V, bool) {
+	if m == nil || m.root == nil {
+		return zeroEntry[K, V]()
+	}
+	n := m.root
+	for n.right != nil {
+		n = n.right
+	}
+	return n.key, n.value, true
+}
+
+// Floor returns the entry with the largest key less than or equal to k.
+func (m *SortedMapFunc[K, V]) Floor(k K) (K, V, bool) {
+	var best *sortedNode[K, V]
+	if m != nil {
+		for n := m.root; n != nil; {
+			c := m.cmp(n.key, k)
+			if c == 0 {
+				return n.key, n.value, true
+			}
+			if c < 0 {
+				best, n = n, n.right
+			} else {
+				n = n.left
+			}
+		}
+	}
+	if best == nil {
+		return zeroEntry[K, V]()
+	}
+	return best.key, best.value, true
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to k.
+func (m *SortedMapFunc[K, V]) Ceiling(k K) (K, V, bool) {
+	var best *sortedNode[K, V]
+	if m != nil {
+		for n := m.root; n != nil; {
+			c := m.cmp(n.key, k)
+			if c == 0 {
+				return n.key, n.value, true
+			}
+			if c > 0 {
+				best, n = n, n.left
+			} else {
+				n = n.right
+			}
+		}
+	}
+	if best == nil {
+		return zeroEntry[K, V]()
+	}
+	return best.key, best.value, true
+}
+
+// Rank returns the number of keys strictly less than k, which is the
+// position k has or would have in iteration order.
+func (m *SortedMapFunc[K, V]) Rank(k K) int {
+	if m == nil {
+		return 0
+	}
+	rank := 0
+	for n := m.root; n != nil; {
+		c := m.cmp(k, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			rank += n.left.len() + 1
+			n = n.right
+		default:
+			return rank + n.left.len()
+		}
+	}
+	return rank
+}
+
+// Select returns the entry at position i in ascending key order, the
+// inverse of Rank. It reports false if i is out of range.
+func (m *SortedMapFunc[K, V]) Select(i int) (K, V, bool) {
+	if i < 0 || i >= m.Len() {
+		return zeroEntry[K, V]()
+	}
+	n := m.root
+	for {
+		switch l := n.left.len(); {
+		case i < l:
+			n = n.left
+		case i > l:
+			i -= l + 1
+			n = n.right
+		default:
+			return n.key, n.value, true
+		}
+	}
+}
+
+// Keys returns the keys in ascending order.
+func (m *SortedMapFunc[K, V]) Keys() []K {
+	if m.Len() == 0 {
+		return nil
+	}
+	keys := make([]K, 0, m.Len())
+	for k := range m.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns the values in ascending key order.
+func (m *SortedMapFunc[K, V]) Values() []V {
+	if m.Len() == 0 {
+		return nil
+	}
+	values := make([]V, 0, m.Len())
+	for _, v := range m.All() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+func (m *SortedMapFunc[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m == nil {
+			return
+		}
+		m.ascend(nil, nil, yield)
+	}
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+func (m *SortedMapFunc[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m == nil {
+			return
+		}
+		m.descend(yield)
+	}
+}
+
+// Range returns an iterator over the entries with lo <= key < hi in
+// ascending order.
+func (m *SortedMapFunc[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m == nil {
+			return
+		}
+		m.ascend(&lo, &hi, yield)
+	}
+}
+
+// ascend yields entries in ascending order starting at the first key >= *lo
+// and stopping before *hi; nil bounds are open.
+func (m *SortedMapFunc[K, V]) ascend(lo, hi *K, yield func(K, V) bool) {
+	stack := m.seekAfter(nil, lo, true)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if hi != nil && m.cmp(n.key, *hi) >= 0 {
+			return
+		}
+		version := m.version
+		if !yield(n.key, n.value) {
+			return
+		}
+		if m.version != version {
+			// The tree was restructured; find our place again.
+			last := n.key
+			stack = m.seekAfter(stack[:0], &last, false)
+			continue
+		}
+		for c := n.right; c != nil; c = c.left {
+			stack = append(stack, c)
+		}
+	}
+}
+
+// descend yields all entries in descending order.
+func (m *SortedMapFunc[K, V]) descend(yield func(K, V) bool) {
+	stack := m.seekBefore(nil, nil)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		version := m.version
+		if !yield(n.key, n.value) {
+			return
+		}
+		if m.version != version {
+			last := n.key
+			stack = m.seekBefore(stack[:0], &last)
+			continue
+		}
+		for c := n.left; c != nil; c = c.right {
+			stack = append(stack, c)
+		}
+	}
+}
+
+// seekAfter pushes the path to the smallest key after k onto stack, leaving
+// it on top. With inclusive set a key equal to k qualifies; a nil k selects
+// the smallest key.
+func (m *SortedMapFunc[K, V]) seekAfter(stack []*sortedNode[K, V], k *K, inclusive bool) []*sortedNode[K, V] {
+	for n := m.root; n != nil; {
+		if k == nil {
+			stack = append(stack, n)
+			n = n.left
+			continue
+		}
+		if c := m.cmp(n.key, *k); c > 0 || (inclusive && c == 0) {
+			stack = append(stack, n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return stack
+}
+
+// seekBefore pushes the path to the largest key less than k onto stack,
+// leaving it on top. A nil k selects the largest key.
+func (m *SortedMapFunc[K, V]) seekBefore(stack []*sortedNode[K, V], k *K) []*sortedNode[K, V] {
+	for n := m.root; n != nil; {
+		if k == nil || m.cmp(n.key, *k) < 0 {
+			stack = append(stack, n)
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return stack
+}
+
+// buildSorted returns a balanced tree holding keys, which must be strictly
+// ascending, with the matching values or zero values if values is nil.
+// Complexity: O(n).
+func buildSorted[K, V any](keys []K, values []V) *sortedNode[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+	mid := len(keys) / 2
+	n := &sortedNode[K, V]{key: keys[mid]}
+	if values != nil {
+		n.value = values[mid]
+		n.left = buildSorted(keys[:mid], values[:mid])
+		n.right = buildSorted(keys[mid+1:], values[mid+1:])
+	} else {
+		n.left = buildSorted[K, V](keys[:mid], nil)
+		n.right = buildSorted[K, V](keys[mid+1:], nil)
+	}
+	n.update()
+	return n
+}
+
+func (m *SortedMapFunc[K, V]) find(k K) *sortedNode[K, V] {
+	if m == nil {
+		return nil
+	}
+	for n := m.root; n != nil; {
+		c := m.cmp(k, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+func (m *SortedMapFunc[K, V]) insert(n *sortedNode[K, V], k K, v V, inserted *bool) *sortedNode[K, V] {
+	if n == nil {
+		*inserted = true
+		return &sortedNode[K, V]{key: k, value: v, height: 1, size: 1}
+	}
+	c := m.cmp(k, n.key)
+	switch {
+	case c < 0:
+		n.left = m.insert(n.left, k, v, inserted)
+	case c > 0:
+		n.right = m.insert(n.right, k, v, inserted)
+	default:
+		n.value = v
+		return n
+	}
+	return n.rebalance()
+}
+
+func (m *SortedMapFunc[K, V]) remove(n *sortedNode[K, V], k K, deleted *bool) *sortedNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	c := m.cmp(k, n.key)
+	switch {
+	case c < 0:
+		n.left = m.remove(n.left, k, deleted)
+	case c > 0:
+		n.right = m.remove(n.right, k, deleted)
+	default:
+		*deleted = true
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		var succ *sortedNode[K, V]
+		right := n.right.removeMin(&succ)
+		succ.left, succ.right = n.left, right
+		return succ.rebalance()
+	}
+	return n.rebalance()
+}
+
+// removeMin detaches the smallest node of the subtree into *min and returns
+// the remaining subtree.
+func (n *sortedNode[K, V]) removeMin(min **sortedNode[K, V]) *sortedNode[K, V] {
+	if n.left == nil {
+		*min = n
+		return n.right
+	}
+	n.left = n.left.removeMin(min)
+	return n.rebalance()
+}
+
+func (n *sortedNode[K, V]) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *sortedNode[K, V]) ht() int8 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *sortedNode[K, V]) update() {
+	n.height = max(n.left.ht(), n.right.ht()) + 1
+	n.size = n.left.len() + n.right.len() + 1
+}
+
+// rebalance restores the AVL invariant at n after one of its subtrees
+// changed height by at most one, and returns the new subtree root.
+func (n *sortedNode[K, V]) rebalance() *sortedNode[K, V] {
+	n.update()
+	switch balance := n.left.ht() - n.right.ht(); {
+	case balance > 1:
+		if n.left.left.ht() < n.left.right.ht() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.ht() < n.right.left.ht() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *sortedNode[K, V]) rotateLeft() *sortedNode[K, V] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *sortedNode[K, V]) rotateRight() *sortedNode[K, V] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+func zeroEntry[K, V any]() (K, V, bool) {
+	var (
+		zk K
+		zv V
+	)
+	return zk, zv, false
+}
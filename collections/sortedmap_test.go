@@ -0,0 +1,332 @@
+package collections
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortedMapBasic(t *testing.T) {
+	m := NewSortedMap[int, string]()
+	for _, k := range []int{5, 1, 9, 3, 7} {
+		m.Set(k, "")
+	}
+	m.Set(3, "three")
+	if m.Len() != 5 {
+		t.Fatalf("len %d", m.Len())
+	}
+	if v, ok := m.Get(3); !ok || v != "three" {
+		t.Fatalf("get 3 = %q %v", v, ok)
+	}
+	if m.Has(4) {
+		t.Fatalf("unexpected key 4")
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []int{1, 3, 5, 7, 9}) {
+		t.Fatalf("keys %v", got)
+	}
+	var back []int
+	for k := range m.Backward() {
+		back = append(back, k)
+	}
+	if !reflect.DeepEqual(back, []int{9, 7, 5, 3, 1}) {
+		t.Fatalf("backward %v", back)
+	}
+	if !m.Delete(5) || m.Delete(5) {
+		t.Fatalf("delete 5")
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []int{1, 3, 7, 9}) {
+		t.Fatalf("keys after delete %v", got)
+	}
+	m.Clear()
+	if m.Len() != 0 || m.Keys() != nil {
+		t.Fatalf("clear")
+	}
+}
+
+func TestSortedMapBounds(t *testing.T) {
+	m := NewSortedMap[int, int]()
+	if _, _, ok := m.Min(); ok {
+		t.Fatalf("min of empty map")
+	}
+	for k := 10; k <= 50; k += 10 {
+		m.Set(k, k*2)
+	}
+	if k, v, ok := m.Min(); !ok || k != 10 || v != 20 {
+		t.Fatalf("min %d %d %v", k, v, ok)
+	}
+	if k, _, ok := m.Max(); !ok || k != 50 {
+		t.Fatalf("max %d %v", k, ok)
+	}
+
+	floors := map[int]int{10: 10, 15: 10, 49: 40, 99: 50}
+	for q, want := range floors {
+		if k, _, ok := m.Floor(q); !ok || k != want {
+			t.Fatalf("floor(%d) = %d %v, want %d", q, k, ok, want)
+		}
+	}
+	if _, _, ok := m.Floor(9); ok {
+		t.Fatalf("floor below min")
+	}
+	ceilings := map[int]int{1: 10, 10: 10, 11: 20, 50: 50}
+	for q, want := range ceilings {
+		if k, _, ok := m.Ceiling(q); !ok || k != want {
+			t.Fatalf("ceiling(%d) = %d %v, want %d", q, k, ok, want)
+		}
+	}
+	if _, _, ok := m.Ceiling(51); ok {
+		t.Fatalf("ceiling above max")
+	}
+
+	ranks := map[int]int{0: 0, 10: 0, 11: 1, 30: 2, 50: 4, 51: 5}
+	for q, want := range ranks {
+		if got := m.Rank(q); got != want {
+			t.Fatalf("rank(%d) = %d, want %d", q, got, want)
+		}
+	}
+}
+
+func TestSortedMapRange(t *testing.T) {
+	m := NewSortedMap[int, int]()
+	for k := range 10 {
+		m.Set(k, k)
+	}
+	collect := func(lo, hi int) []int {
+		var out []int
+		for k := range m.Range(lo, hi) {
+			out = append(out, k)
+		}
+		return out
+	}
+	if got := collect(3, 7); !reflect.DeepEqual(got, []int{3, 4, 5, 6}) {
+		t.Fatalf("range [3,7) = %v", got)
+	}
+	if got := collect(-5, 2); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatalf("range [-5,2) = %v", got)
+	}
+	if got := collect(5, 5); got != nil {
+		t.Fatalf("empty range = %v", got)
+	}
+	if got := collect(8, 100); !reflect.DeepEqual(got, []int{8, 9}) {
+		t.Fatalf("range [8,100) = %v", got)
+	}
+
+	var first []int
+	for k := range m.Range(2, 9) {
+		if len(first) == 2 {
+			break
+		}
+		first = append(first, k)
+	}
+	if !reflect.DeepEqual(first, []int{2, 3}) {
+		t.Fatalf("early break %v", first)
+	}
+}
+
+func TestSortedMapFunc(t *testing.T) {
+	m := NewSortedMapFunc[string, int](func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	m.Set("banana", 1)
+	m.Set("Apple", 2)
+	m.Set("cherry", 3)
+	m.Set("APPLE", 4)
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"Apple", "banana", "cherry"}) {
+		t.Fatalf("keys %v", got)
+	}
+	if v, _ := m.Get("apple"); v != 4 {
+		t.Fatalf("get apple = %d", v)
+	}
+}
+
+func TestSortedMapMutationDuringIteration(t *testing.T) {
+	m := NewSortedMap[int, int]()
+	for k := 0; k < 20; k += 2 {
+		m.Set(k, k)
+	}
+	var seen []int
+	for k := range m.All() {
+		seen = append(seen, k)
+		switch k {
+		case 4:
+			m.Delete(6) // not yet reached: skipped
+			m.Delete(2) // already produced
+			m.Set(5, 5) // ahead of the cursor: produced
+			m.Set(1, 1) // behind the cursor: not produced
+		case 10:
+			m.Delete(10) // deleting the current key is safe
+		}
+	}
+	if want := []int{0, 2, 4, 5, 8, 10, 12, 14, 16, 18}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("seen %v, want %v", seen, want)
+	}
+
+	seen = seen[:0]
+	for k := range m.Backward() {
+		seen = append(seen, k)
+		if k == 14 {
+			m.Delete(12)
+			m.Set(13, 13)
+		}
+	}
+	if want := []int{18, 16, 14, 13, 8, 5, 4, 1, 0}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("backward seen %v, want %v", seen, want)
+	}
+}
+
+func TestSortedMapRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewSortedMap[int, int]()
+	ref := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		k := rng.Intn(500)
+		if rng.Intn(3) == 0 {
+			_, want := ref[k]
+			if got := m.Delete(k); got != want {
+				t.Fatalf("delete(%d) = %v, want %v", k, got, want)
+			}
+			delete(ref, k)
+		} else {
+			m.Set(k, i)
+			ref[k] = i
+		}
+		if i%250 == 0 {
+			checkSortedMap(t, m, ref)
+		}
+	}
+	checkSortedMap(t, m, ref)
+}
+
+// checkSortedMap compares m with ref and verifies the AVL invariants.
+func checkSortedMap(t *testing.T, m *SortedMap[int, int], ref map[int]int) {
+	t.Helper()
+	keys := make([]int, 0, len(ref))
+	for k := range ref {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if got := m.Keys(); !slices.Equal(got, keys) {
+		t.Fatalf("keys %v, want %v", got, keys)
+	}
+	for i, k := range keys {
+		if v, ok := m.Get(k); !ok || v != ref[k] {
+			t.Fatalf("get(%d) = %d %v, want %d", k, v, ok, ref[k])
+		}
+		if r := m.Rank(k); r != i {
+			t.Fatalf("rank(%d) = %d, want %d", k, r, i)
+		}
+		if got, _, ok := m.Select(i); !ok || got != k {
+			t.Fatalf("select(%d) = %d %v, want %d", i, got, ok, k)
+		}
+	}
+	var walk func(n *sortedNode[int, int]) int8
+	walk = func(n *sortedNode[int, int]) int8 {
+		if n == nil {
+			return 0
+		}
+		l, r := walk(n.left), walk(n.right)
+		if l-r > 1 || r-l > 1 {
+			t.Fatalf("node %d unbalanced: %d vs %d", n.key, l, r)
+		}
+		if n.size != n.left.len()+n.right.len()+1 {
+			t.Fatalf("node %d size %d", n.key, n.size)
+		}
+		if h := max(l, r) + 1; n.height != h {
+			t.Fatalf("node %d height %d, want %d", n.key, n.height, h)
+		}
+		return n.height
+	}
+	walk(m.f.root)
+}
+
+func TestSortedMapNil(t *testing.T) {
+	var m *SortedMap[int, int]
+	m.Set(1, 1)
+	m.Clear()
+	if m.Len() != 0 || m.Has(1) || m.Delete(1) || m.Rank(1) != 0 || m.Keys() != nil {
+		t.Fatalf("nil map should behave as empty")
+	}
+	if _, _, ok := m.Floor(1); ok {
+		t.Fatalf("nil floor")
+	}
+	for range m.All() {
+		t.Fatalf("nil map yielded")
+	}
+}
+
+func TestSortedMapZeroValue(t *testing.T) {
+	var m SortedMap[int, int]
+	if m.Len() != 0 || m.Has(1) || m.Delete(1) {
+		t.Fatalf("zero map should read as empty")
+	}
+	for _, k := range []int{3, 1, 2} {
+		m.Set(k, k*10)
+	}
+	if got := m.Keys(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("keys %v", got)
+	}
+	checkSortedMap(t, &m, map[int]int{1: 10, 2: 20, 3: 30})
+}
+
+func TestSortedMapFuncZeroValue(t *testing.T) {
+	var m SortedMapFunc[int, int]
+	if m.Len() != 0 || m.Has(1) || m.Delete(1) {
+		t.Fatalf("zero map should read as empty")
+	}
+	defer func() {
+		if msg, _ := recover().(string); !strings.Contains(msg, "NewSortedMapFunc") {
+			t.Fatalf("Set on a zero map should panic pointing at NewSortedMapFunc, got %q", msg)
+		}
+	}()
+	m.Set(1, 1)
+}
+
+func BenchmarkSortedMapSet(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(benchEntries)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m := NewSortedMap[int, int]()
+		for _, k := range keys {
+			m.Set(k, k)
+		}
+	}
+}
+
+func BenchmarkSortedMapGet(b *testing.B) {
+	m := NewSortedMap[int, int]()
+	for k := range benchEntries {
+		m.Set(k, k)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get(i % benchEntries)
+	}
+}
+
+func BenchmarkSortedMapFloor(b *testing.B) {
+	m := NewSortedMap[int, int]()
+	for k := range benchEntries {
+		m.Set(k*2, k)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Floor(i % (2 * benchEntries))
+	}
+}
+
+func BenchmarkSortedMapIterate(b *testing.B) {
+	m := NewSortedMap[int, int]()
+	for k := range benchEntries {
+		m.Set(k, k)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum := 0
+		for _, v := range m.All() {
+			sum += v
+		}
+		_ = sum
+	}
+}
@@ -0,0 +1,428 @@
+package collections
+
+import (
+	"cmp"
+	"iter"
+)
+
+// SortedSet is a set that keeps its elements in sorted order.
+//
+// It is backed by a SortedMap, so Add, Remove, Has, Floor, Ceiling, Rank and
+// Select are O(log n) and iterating over n elements is O(n). The copying set
+// algebra merges the two sorted element sequences and builds the result in
+// O(n+m). Elements are ordered by cmp.Compare; use SortedSetFunc for a custom
+// ordering. The zero value is an empty set ready to use, and methods on a nil
+// *SortedSet behave like an empty set.
+type SortedSet[T cmp.Ordered] struct {
+	f SortedSetFunc[T]
+}
+
+// NewSortedSet creates an empty set ordered by cmp.Compare.
+func NewSortedSet[T cmp.Ordered]() *SortedSet[T] {
+	return &SortedSet[T]{f: SortedSetFunc[T]{m: SortedMapFunc[T, struct{}]{cmp: cmp.Compare[T]}}}
+}
+
+// NewSortedSetFromSlice creates a set ordered by cmp.Compare containing the
+// elements of the slice.
+func NewSortedSetFromSlice[T cmp.Ordered](s []T) *SortedSet[T] {
+	set := NewSortedSet[T]()
+	for _, v := range s {
+		set.Add(v)
+	}
+	return set
+}
+
+func (s *SortedSet[T]) Add(v T) {
+	if s == nil {
+		return
+	}
+	if s.f.m.cmp == nil {
+		s.f.m.cmp = cmp.Compare[T]
+	}
+	s.f.Add(v)
+}
+
+func (s *SortedSet[T]) Remove(v T) {
+	s.funcs().Remove(v)
+}
+
+func (s *SortedSet[T]) Has(v T) bool {
+	return s.funcs().Has(v)
+}
+
+func (s *SortedSet[T]) Len() int {
+	return s.funcs().Len()
+}
+
+func (s *SortedSet[T]) Clear() {
+	s.funcs().Clear()
+}
+
+// Min returns the smallest element.
+func (s *SortedSet[T]) Min() (T, bool) {
+	return s.funcs().Min()
+}
+
+// Max returns the largest element.
+func (s *SortedSet[T]) Max() (T, bool) {
+	return s.funcs().Max()
+}
+
+// Floor returns the largest element less than or equal to v.
+func (s *SortedSet[T]) Floor(v T) (T, bool) {
+	return s.funcs().Floor(v)
+}
+
+// Ceiling returns the smallest element greater than or equal to v.
+func (s *SortedSet[T]) Ceiling(v T) (T, bool) {
+	return s.funcs().Ceiling(v)
+}
+
+// Rank returns the number of elements strictly less than v.
+func (s *SortedSet[T]) Rank(v T) int {
+	return s.funcs().Rank(v)
+}
+
+// Select returns the element at position i in ascending order, the inverse
+// of Rank. It reports false if i is out of range.
+func (s *SortedSet[T]) Select(i int) (T, bool) {
+	return s.funcs().Select(i)
+}
+
+// All returns an iterator over the elements in ascending order. The set may
+// be modified during iteration as described on SortedMap.
+func (s *SortedSet[T]) All() iter.Seq[T] {
+	return s.funcs().All()
+}
+
+// Backward returns an iterator over the elements in descending order.
+func (s *SortedSet[T]) Backward() iter.Seq[T] {
+	return s.funcs().Backward()
+}
+
+// Range returns an iterator over the elements with lo <= v < hi in ascending
+// order.
+func (s *SortedSet[T]) Range(lo, hi T) iter.Seq[T] {
+	return s.funcs().Range(lo, hi)
+}
+
+// Values returns the elements in ascending order.
+func (s *SortedSet[T]) Values() []T {
+	return s.funcs().Values()
+}
+
+// ToSlice returns the elements in ascending order.
+func (s *SortedSet[T]) ToSlice() []T {
+	return s.Values()
+}
+
+// Clone returns a copy of the set. Complexity: O(n).
+func (s *SortedSet[T]) Clone() *SortedSet[T] {
+	return wrapSortedSet(s.funcs().Clone())
+}
+
+// Union returns a new set with the elements of s and other.
+func (s *SortedSet[T]) Union(other *SortedSet[T]) *SortedSet[T] {
+	return wrapSortedSet(s.funcs().Union(other.funcs()))
+}
+
+// Intersection returns a new set with the elements in both s and other.
+func (s *SortedSet[T]) Intersection(other *SortedSet[T]) *SortedSet[T] {
+	return wrapSortedSet(s.funcs().Intersection(other.funcs()))
+}
+
+// Difference returns a new set with the elements of s that are not in
+// other.
+func (s *SortedSet[T]) Difference(other *SortedSet[T]) *SortedSet[T] {
+	return wrapSortedSet(s.funcs().Difference(other.funcs()))
+}
+
+// SymmetricDifference returns a new set with the elements in exactly one of
+// s and other.
+func (s *SortedSet[T]) SymmetricDifference(other *SortedSet[T]) *SortedSet[T] {
+	return wrapSortedSet(s.funcs().SymmetricDifference(other.funcs()))
+}
+
+func (s *SortedSet[T]) IsSubset(other *SortedSet[T]) bool {
+	return s.funcs().IsSubset(other.funcs())
+}
+
+func (s *SortedSet[T]) IsSuperset(other *SortedSet[T]) bool {
+	return s.funcs().IsSuperset(other.funcs())
+}
+
+func (s *SortedSet[T]) IsDisjoint(other *SortedSet[T]) bool {
+	return s.funcs().IsDisjoint(other.funcs())
+}
+
+// Equal reports whether s and other contain the same elements. A nil set
+// equals an empty one.
+func (s *SortedSet[T]) Equal(other *SortedSet[T]) bool {
+	return s.funcs().Equal(other.funcs())
+}
+
+func (s *SortedSet[T]) funcs() *SortedSetFunc[T] {
+	if s == nil {
+		return nil
+	}
+	return &s.f
+}
+
+// wrapSortedSet turns a result computed on the underlying SortedSetFunc back
+// into a SortedSet. Results involving a zero-value set may have no ordering
+// yet, so it is set here.
+func wrapSortedSet[T cmp.Ordered](f *SortedSetFunc[T]) *SortedSet[T] {
+	if f == nil {
+		return nil
+	}
+	f.m.cmp = cmp.Compare[T]
+	return &SortedSet[T]{f: *f}
+}
+
+// SortedSetFunc is a SortedSet ordered by a comparison function, for
+// elements that are not cmp.Ordered or need a different order. It has the
+// same methods and guarantees as SortedSet, and the set algebra requires
+// both sets to use the same ordering. A zero-value SortedSetFunc has no
+// ordering: it must be created with NewSortedSetFunc, and Add on the zero
+// value panics. Methods on a nil *SortedSetFunc behave like an empty set.
+type SortedSetFunc[T any] struct {
+	m SortedMapFunc[T, struct{}]
+}
+
+// NewSortedSetFunc creates an empty set ordered by the comparison function,
+// which must return a negative number when a < b, zero when a == b and a
+// positive number when a > b.
+func NewSortedSetFunc[T any](cmp func(a, b T) int) *SortedSetFunc[T] {
+	return &SortedSetFunc[T]{m: SortedMapFunc[T, struct{}]{cmp: cmp}}
+}
+
+// Add inserts v. It panics on a zero-value SortedSetFunc, which has no
+// ordering.
+func (s *SortedSetFunc[T]) Add(v T) {
+	if s == nil {
+		return
+	}
+	if s.m.cmp == nil {
+		panic("collections: zero-value SortedSetFunc has no ordering; create it with NewSortedSetFunc")
+	}
+	s.m.Set(v, struct{}{})
+}
+
+func (s *SortedSetFunc[T]) Remove(v T) {
+	if s == nil {
+		return
+	}
+	s.m.Delete(v)
+}
+
+func (s *SortedSetFunc[T]) Has(v T) bool {
+	if s == nil {
+		return false
+	}
+	return s.m.Has(v)
+}
+
+func (s *SortedSetFunc[T]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.m.Len()
+}
+
+func (s *SortedSetFunc[T]) Clear() {
+	if s == nil {
+		return
+	}
+	s.m.Clear()
+}
+
+// Min returns the smallest element.
+func (s *SortedSetFunc[T]) Min() (T, bool) {
+	return s.key(s.tree().Min())
+}
+
+// Max returns the largest element.
+func (s *SortedSetFunc[T]) Max() (T, bool) {
+	return s.key(s.tree().Max())
+}
+
+// Floor returns the largest element less than or equal to v.
+func (s *SortedSetFunc[T]) Floor(v T) (T, bool) {
+	return s.key(s.tree().Floor(v))
+}
+
+// Ceiling returns the smallest element greater than or equal to v.
+func (s *SortedSetFunc[T]) Ceiling(v T) (T, bool) {
+	return s.key(s.tree().Ceiling(v))
+}
+
+// Rank returns the number of elements strictly less than v.
+func (s *SortedSetFunc[T]) Rank(v T) int {
+	return s.tree().Rank(v)
+}
+
+// Select returns the element at position i in ascending order, the inverse
+// of Rank. It reports false if i is out of range.
+func (s *SortedSetFunc[T]) Select(i int) (T, bool) {
+	return s.key(s.tree().Select(i))
+}
+
+// All returns an iterator over the elements in ascending order. The set may
+// be modified during iteration as described on SortedMap.
+func (s *SortedSetFunc[T]) All() iter.Seq[T] {
+	return keysOf(s.tree().All())
+}
+
+// Backward returns an iterator over the elements in descending order.
+func (s *SortedSetFunc[T]) Backward() iter.Seq[T] {
+	return keysOf(s.tree().Backward())
+}
+
+// Range returns an iterator over the elements with lo <= v < hi in ascending
+// order.
+func (s *SortedSetFunc[T]) Range(lo, hi T) iter.Seq[T] {
+	return keysOf(s.tree().Range(lo, hi))
+}
+
+// Values returns the elements in ascending order.
+func (s *SortedSetFunc[T]) Values() []T {
+	return s.tree().Keys()
+}
+
+// ToSlice returns the elements in ascending order.
+func (s *SortedSetFunc[T]) ToSlice() []T {
+	return s.Values()
+}
+
+// Clone returns a copy of the set with the same ordering. Complexity: O(n).
+func (s *SortedSetFunc[T]) Clone() *SortedSetFunc[T] {
+	if s == nil {
+		return nil
+	}
+	return s.build(s.Values())
+}
+
+// Union returns a new set with the elements of s and other.
+func (s *SortedSetFunc[T]) Union(other *SortedSetFunc[T]) *SortedSetFunc[T] {
+	return s.merge(other, true, true, true)
+}
+
+// Intersection returns a new set with the elements in both s and other.
+func (s *SortedSetFunc[T]) Intersection(other *SortedSetFunc[T]) *SortedSetFunc[T] {
+	return s.merge(other, false, true, false)
+}
+
+// Difference returns a new set with the elements of s that are not in
+// other.
+func (s *SortedSetFunc[T]) Difference(other *SortedSetFunc[T]) *SortedSetFunc[T] {
+	return s.merge(other, true, false, false)
+}
+
+// SymmetricDifference returns a new set with the elements in exactly one of
+// s and other.
+func (s *SortedSetFunc[T]) SymmetricDifference(other *SortedSetFunc[T]) *SortedSetFunc[T] {
+	return s.merge(other, true, false, true)
+}
+
+func (s *SortedSetFunc[T]) IsSubset(other *SortedSetFunc[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for v := range s.All() {
+		if !other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SortedSetFunc[T]) IsSuperset(other *SortedSetFunc[T]) bool {
+	return other.IsSubset(s)
+}
+
+func (s *SortedSetFunc[T]) IsDisjoint(other *SortedSetFunc[T]) bool {
+	if s.Len() > other.Len() {
+		s, other = other, s
+	}
+	for v := range s.All() {
+		if other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and other contain the same elements. A nil set
+// equals an empty one.
+func (s *SortedSetFunc[T]) Equal(other *SortedSetFunc[T]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
+
+// merge walks the elements of s and other in order, keeping elements found
+// only in s, in both, or only in other as requested, and builds the result
+// from the merged slice. Results of a nil receiver use other's ordering.
+func (s *SortedSetFunc[T]) merge(other *SortedSetFunc[T], onlyLeft, both, onlyRight bool) *SortedSetFunc[T] {
+	if s == nil {
+		if other == nil {
+			return nil
+		}
+		s = &SortedSetFunc[T]{m: SortedMapFunc[T, struct{}]{cmp: other.m.cmp}}
+	}
+	a, b := s.Values(), other.Values()
+	out := make([]T, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch c := s.m.cmp(a[i], b[j]); {
+		case c < 0:
+			if onlyLeft {
+				out = append(out, a[i])
+			}
+			i++
+		case c > 0:
+			if onlyRight {
+				out = append(out, b[j])
+			}
+			j++
+		default:
+			if both {
+				out = append(out, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	if onlyLeft {
+		out = append(out, a[i:]...)
+	}
+	if onlyRight {
+		out = append(out, b[j:]...)
+	}
+	return s.build(out)
+}
+
+// build returns a set with s's ordering holding sorted, which must be
+// strictly ascending.
+func (s *SortedSetFunc[T]) build(sorted []T) *SortedSetFunc[T] {
+	return &SortedSetFunc[T]{m: SortedMapFunc[T, struct{}]{cmp: s.m.cmp, root: buildSorted[T, struct{}](sorted, nil)}}
+}
+
+func (s *SortedSetFunc[T]) tree() *SortedMapFunc[T, struct{}] {
+	if s == nil {
+		return nil
+	}
+	return &s.m
+}
+
+func (s *SortedSetFunc[T]) key(v T, _ struct{}, ok bool) (T, bool) {
+	return v, ok
+}
+
+func keysOf[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package collections
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortedSetBasic(t *testing.T) {
+	s := NewSortedSetFromSlice([]int{5, 1, 9, 3, 5})
+	if got := s.Values(); !reflect.DeepEqual(got, []int{1, 3, 5, 9}) {
+		t.Fatalf("values %v", got)
+	}
+	s.Remove(3)
+	s.Add(7)
+	if !s.Has(7) || s.Has(3) || s.Len() != 4 {
+		t.Fatalf("add/remove %v", s.Values())
+	}
+	var back []int
+	for v := range s.Backward() {
+		back = append(back, v)
+	}
+	if !reflect.DeepEqual(back, []int{9, 7, 5, 1}) {
+		t.Fatalf("backward %v", back)
+	}
+	s.Clear()
+	if s.Len() != 0 {
+		t.Fatalf("clear")
+	}
+}
+
+func TestSortedSetQueries(t *testing.T) {
+	s := NewSortedSetFromSlice([]int{10, 20, 30, 40})
+	if v, ok := s.Min(); !ok || v != 10 {
+		t.Fatalf("min %d", v)
+	}
+	if v, ok := s.Max(); !ok || v != 40 {
+		t.Fatalf("max %d", v)
+	}
+	if v, ok := s.Ceiling(21); !ok || v != 30 {
+		t.Fatalf("ceiling %d", v)
+	}
+	if v, ok := s.Floor(21); !ok || v != 20 {
+		t.Fatalf("floor %d", v)
+	}
+	if _, ok := s.Ceiling(41); ok {
+		t.Fatalf("ceiling past max")
+	}
+	for i, v := range s.Values() {
+		if s.Rank(v) != i {
+			t.Fatalf("rank(%d) = %d", v, s.Rank(v))
+		}
+		if got, ok := s.Select(i); !ok || got != v {
+			t.Fatalf("select(%d) = %d", i, got)
+		}
+	}
+	if _, ok := s.Select(4); ok {
+		t.Fatalf("select out of range")
+	}
+	if _, ok := s.Select(-1); ok {
+		t.Fatalf("select negative")
+	}
+	var got []int
+	for v := range s.Range(15, 40) {
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, []int{20, 30}) {
+		t.Fatalf("range %v", got)
+	}
+}
+
+func TestSortedSetAlgebra(t *testing.T) {
+	a := NewSortedSetFromSlice([]int{1, 2, 3, 4})
+	b := NewSortedSetFromSlice([]int{3, 4, 5})
+	cases := []struct {
+		name string
+		got  *SortedSet[int]
+		want []int
+	}{
+		{"union", a.Union(b), []int{1, 2, 3, 4, 5}},
+		{"intersection", a.Intersection(b), []int{3, 4}},
+		{"difference", a.Difference(b), []int{1, 2}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 2, 5}},
+		{"clone", a.Clone(), []int{1, 2, 3, 4}},
+		{"nil union", (*SortedSet[int])(nil).Union(b), []int{3, 4, 5}},
+		{"union with nil", a.Union(nil), []int{1, 2, 3, 4}},
+	}
+	for _, c := range cases {
+		if got := c.got.Values(); !slices.Equal(got, c.want) {
+			t.Fatalf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+	if !NewSortedSetFromSlice([]int{3, 4}).IsSubset(a) || a.IsSubset(b) || !a.IsSuperset(NewSortedSetFromSlice([]int{1})) {
+		t.Fatalf("subset")
+	}
+	if a.IsDisjoint(b) || !a.IsDisjoint(NewSortedSetFromSlice([]int{9})) {
+		t.Fatalf("disjoint")
+	}
+	if !a.Equal(a.Clone()) || a.Equal(b) {
+		t.Fatalf("equal")
+	}
+
+	// Results built by merging are ordinary balanced sets.
+	u := a.Union(b)
+	u.Add(0)
+	u.Remove(3)
+	if got := u.Values(); !slices.Equal(got, []int{0, 1, 2, 4, 5}) {
+		t.Fatalf("update after merge %v", got)
+	}
+}
+
+func TestSortedSetFunc(t *testing.T) {
+	byLen := func(a, b string) int {
+		if c := len(a) - len(b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	}
+	a := NewSortedSetFunc(byLen)
+	b := NewSortedSetFunc(byLen)
+	for _, v := range []string{"ccc", "a", "bb"} {
+		a.Add(v)
+	}
+	for _, v := range []string{"dddd", "a", "zz"} {
+		b.Add(v)
+	}
+	if got := a.Union(b).Values(); !slices.Equal(got, []string{"a", "bb", "zz", "ccc", "dddd"}) {
+		t.Fatalf("union %v", got)
+	}
+}
+
+func TestSortedSetRandomizedMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		a, b := NewSortedSet[int](), NewSortedSet[int]()
+		sa, sb := NewSet[int](), NewSet[int]()
+		for range rng.Intn(200) {
+			v := rng.Intn(300)
+			a.Add(v)
+			sa.Add(v)
+		}
+		for range rng.Intn(200) {
+			v := rng.Intn(300)
+			b.Add(v)
+			sb.Add(v)
+		}
+		for _, c := range []struct {
+			got  *SortedSet[int]
+			want *Set[int]
+		}{
+			{a.Union(b), sa.Union(sb)},
+			{a.Intersection(b), sa.Intersection(sb)},
+			{a.Difference(b), sa.Difference(sb)},
+			{a.SymmetricDifference(b), sa.SymmetricDifference(sb)},
+		} {
+			vals := c.got.Values()
+			if len(vals) != c.want.Len() || !c.want.ContainsAll(vals...) || !slices.IsSorted(vals) {
+				t.Fatalf("round %d: %v", round, vals)
+			}
+			ref := map[int]int{}
+			for _, v := range vals {
+				ref[v] = 0
+			}
+			checkSortedMap(t, sortedSetAsMap(c.got), ref)
+		}
+	}
+}
+
+// sortedSetAsMap exposes a SortedSet's tree for invariant checks.
+func sortedSetAsMap(s *SortedSet[int]) *SortedMap[int, int] {
+	m := NewSortedMap[int, int]()
+	m.f.root = convertSortedTree(s.f.m.root)
+	return m
+}
+
+func convertSortedTree(n *sortedNode[int, struct{}]) *sortedNode[int, int] {
+	if n == nil {
+		return nil
+	}
+	return &sortedNode[int, int]{key: n.key, left: convertSortedTree(n.left), right: convertSortedTree(n.right), height: n.height, size: n.size}
+}
+
+func TestSortedSetNil(t *testing.T) {
+	var s *SortedSet[int]
+	s.Add(1)
+	s.Remove(1)
+	s.Clear()
+	if s.Has(1) || s.Len() != 0 || s.Rank(3) != 0 || s.Values() != nil || s.Clone() != nil {
+		t.Fatalf("nil set should behave as empty")
+	}
+	if _, ok := s.Min(); ok {
+		t.Fatalf("nil min")
+	}
+	for range s.Range(0, 10) {
+		t.Fatalf("nil range yielded")
+	}
+}
+
+func TestSortedSetZeroValue(t *testing.T) {
+	var a, b SortedSet[int]
+	if u := a.Union(&b); u.Len() != 0 {
+		t.Fatalf("union of zero sets %v", u.Values())
+	}
+	u := a.Union(NewSortedSetFromSlice([]int{3, 1}))
+	u.Add(2)
+	if got := u.Values(); !slices.Equal(got, []int{1, 2, 3}) || !u.Has(3) {
+		t.Fatalf("union with zero set %v", got)
+	}
+	for _, v := range []int{5, 4} {
+		a.Add(v)
+	}
+	if got := a.Values(); !slices.Equal(got, []int{4, 5}) {
+		t.Fatalf("values %v", got)
+	}
+
+	var f SortedSetFunc[int]
+	defer func() {
+		if msg, _ := recover().(string); !strings.Contains(msg, "NewSortedSetFunc") {
+			t.Fatalf("Add on a zero SortedSetFunc should panic pointing at NewSortedSetFunc, got %q", msg)
+		}
+	}()
+	f.Add(1)
+}
+
+func BenchmarkSortedSetUnion(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := NewSortedSet[int](), NewSortedSet[int]()
+	for range 50_000 {
+		x.Add(rng.Int())
+		y.Add(rng.Int())
+	}
+	b.Run("merge", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			x.Union(y)
+		}
+	})
+	b.Run("insert", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			u := x.Clone()
+			for v := range y.All() {
+				u.Add(v)
+			}
+		}
+	})
+}